@@ -0,0 +1,40 @@
+// Package weights loads the weighted evaluators of the commands
+package weights
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+var files []string
+
+// AddFlag adds the --weights flag to the persistent flags of a command
+func AddFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringArrayVar(&files, "weights", nil, "file of evaluator weights, such as written by tune")
+}
+
+// Register names the weighted evaluators of the config file
+// and then of any weights files, such as those written by tune
+func Register() {
+	report(game.RegisterWeightsFrom(viper.GetViper()))
+	for _, f := range files {
+		v := viper.New()
+		v.SetConfigFile(f)
+		if err := v.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: weights %s: %v\n", f, err)
+			continue
+		}
+		report(game.RegisterWeightsFrom(v))
+	}
+}
+
+func report(errs []error) {
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/protocol"
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+var analysePosition string
+var analyseDepth int
+var analyseMultiPV int
+var analyseFormat string
+var analyseEvaluator string
+var analyseHash int
+
+// analysis is the json output of analyse
+type analysis struct {
+	Position  string        `json:"position"`
+	Depth     int           `json:"depth"`
+	Evaluator string        `json:"evaluator"`
+	Nodes     uint64        `json:"nodes"`
+	Lines     []search.Line `json:"lines"`
+}
+
+// analyseCmd shows the best moves of a position
+var analyseCmd = &cobra.Command{
+	Use:   "analyse",
+	Short: "Show the best moves of a position",
+	Long: `Analyse a position with minimax, showing the best moves with
+their scores, from the side to move, and principal variations.
+Variations are in the game notation, a word for each turn with the
+moves of a turn joined by +, so 4+3 1 is 4 giving a repeat turn,
+then 3, then 1 for the opponent. For example:
+
+mconsole analyse --position 0,4,4,4,4,4,4,0,4,4,4,4,4,4 --depth 10 --multipv 3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		game.DefineGame(
+			viper.GetInt("game.width"),
+			viper.GetInt("game.stones"),
+		)
+		pos := game.StartPosition()
+		if csv := viper.GetString("analyse.position"); csv != "" {
+			var err error
+			if pos, err = protocol.ParsePosition(csv); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+		}
+		if pos.IsGameEnd() {
+			fmt.Fprintf(os.Stderr, "error: position %s is the end of the game\n", pos.AsCsv())
+			return
+		}
+		format := viper.GetString("analyse.format")
+		if format != "text" && format != "json" {
+			fmt.Fprintf(os.Stderr, "error: invalid format %q, must be one of: text, json\n", format)
+			return
+		}
+		name := viper.GetString("analyse.evaluator")
+		eval, err := game.LookupEvaluator(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		m := &search.Minimax{Depth: viper.GetInt("analyse.depth"), Eval: eval}
+		if m.Depth < 1 {
+			fmt.Fprintf(os.Stderr, "error: depth must be at least 1\n")
+			return
+		}
+		if mb := viper.GetInt("analyse.hash"); mb > 0 {
+			m.Table = search.NewTable(mb)
+		}
+
+		lines, err := m.Analyse(context.Background(), pos, viper.GetInt("analyse.multipv"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		a := analysis{Position: pos.AsCsv(), Depth: m.Depth, Evaluator: name, Nodes: m.Nodes, Lines: lines}
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(a); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+			return
+		}
+		pos.Show()
+		fmt.Printf("position %s depth %d evaluator %s nodes %d\n", a.Position, a.Depth, a.Evaluator, a.Nodes)
+		for i, l := range lines {
+			fmt.Printf("%2d  %2d  %+8.2f  %s\n", i+1, l.Move, l.Score, l.Notation)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyseCmd)
+
+	analyseCmd.Flags().StringVar(&analysePosition, "position", "", "position csv from the side to move (default is the start)")
+	analyseCmd.Flags().IntVar(&analyseDepth, "depth", 8, "plies to search")
+	analyseCmd.Flags().IntVar(&analyseMultiPV, "multipv", 1, "best moves to show, 0 for all")
+	analyseCmd.Flags().StringVar(&analyseFormat, "format", "text", "output format of <text|json>")
+	analyseCmd.Flags().StringVar(&analyseEvaluator, "evaluator", "store", "evaluator scoring positions at the depth, see players")
+	analyseCmd.Flags().IntVar(&analyseHash, "hash", 64, "megabytes of transposition table, 0 for none")
+
+	viper.BindPFlag("analyse.position", analyseCmd.Flags().Lookup("position"))
+	viper.BindPFlag("analyse.depth", analyseCmd.Flags().Lookup("depth"))
+	viper.BindPFlag("analyse.multipv", analyseCmd.Flags().Lookup("multipv"))
+	viper.BindPFlag("analyse.format", analyseCmd.Flags().Lookup("format"))
+	viper.BindPFlag("analyse.evaluator", analyseCmd.Flags().Lookup("evaluator"))
+	viper.BindPFlag("analyse.hash", analyseCmd.Flags().Lookup("hash"))
+}
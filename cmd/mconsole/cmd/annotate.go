@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/record"
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+var annotateMoves string
+var annotateGame int
+var annotateDepth int
+var annotateEvaluator string
+var annotateHash int
+var annotateThreshold float64
+var annotateOutput string
+
+// annotateCmd reports the mistakes of recorded games
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [record file]",
+	Short: "Report the mistakes of recorded games",
+	Long: `Annotate games by analysing every move with minimax, reporting
+for each side the moves that lost at least the threshold, with the better
+move and its line, and an accuracy, 100% always playing the best move and
+0% the worst. Games are read from a record file, as saved by --record of
+mconsole or match, or given as moves in the game notation. The annotated
+records can be saved with the mistakes as comments. For example:
+
+mconsole match --p1 minimax --p2 random --games 10 --record games.txt
+mconsole annotate games.txt --depth 10 --output annotated.txt
+mconsole annotate --moves "4+3 1 2 6" --depth 8`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var records []*record.Record
+		switch {
+		case len(args) == 1:
+			f, err := os.Open(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			records, err = record.Read(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s: %v\n", args[0], err)
+				return
+			}
+		case annotateMoves != "":
+			r := record.New(viper.GetInt("game.width"), viper.GetInt("game.stones"))
+			moves, err := game.ParseLine(annotateMoves)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			r.Moves = moves
+			if _, err := r.Replay(); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			records = append(records, r)
+		default:
+			fmt.Fprintf(os.Stderr, "error: a record file or --moves is required\n")
+			return
+		}
+		if n := viper.GetInt("annotate.game"); n > 0 {
+			if n > len(records) {
+				fmt.Fprintf(os.Stderr, "error: game %d not found, %d in the file\n", n, len(records))
+				return
+			}
+			records = records[n-1 : n]
+		}
+
+		name := viper.GetString("annotate.evaluator")
+		eval, err := game.LookupEvaluator(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		depth := viper.GetInt("annotate.depth")
+		threshold := viper.GetFloat64("annotate.threshold")
+		if threshold <= 0 {
+			fmt.Fprintf(os.Stderr, "error: threshold %g must be above 0\n", threshold)
+			return
+		}
+		for i, r := range records {
+			game.DefineGame(r.Width, r.Stones)
+			m := &search.Minimax{Depth: depth, Eval: eval}
+			if mb := viper.GetInt("annotate.hash"); mb > 0 {
+				m.Table = search.NewTable(mb)
+			}
+			report, err := record.Annotate(context.Background(), r, m, threshold)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: game %d: %v\n", i+1, err)
+				return
+			}
+			r.Tags["annotator"] = fmt.Sprintf("minimax depth %d evaluator %s", depth, name)
+			if i > 0 {
+				fmt.Println()
+			}
+			writeReport(os.Stdout, i+1, r, report)
+		}
+
+		if path := viper.GetString("annotate.output"); path != "" {
+			if err := writeRecords(path, records); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+		}
+	},
+}
+
+// writeReport shows the accuracy of each side and their mistakes
+func writeReport(w io.Writer, n int, r *record.Record, report *record.Report) {
+	names := [2]string{"p1", "p2"}
+	for side := range names {
+		if p := r.Tags[names[side]]; p != "" {
+			names[side] += " " + p
+		}
+	}
+	fmt.Fprintf(w, "game %d  %s vs %s", n, names[0], names[1])
+	if result := r.Tags["result"]; result != "" {
+		fmt.Fprintf(w, "  %s", result)
+	}
+	fmt.Fprintln(w)
+	for side, name := range names {
+		fmt.Fprintf(w, "%-16s accuracy %5.1f%%  mistakes %d  blunders %d\n",
+			name, report.Accuracy[side], report.Mistakes[side], report.Blunders[side])
+	}
+	for _, note := range report.Notes {
+		if note.Mark == "" {
+			continue
+		}
+		fmt.Fprintf(w, "move %3d  p%d played %d %+g  %-2s best %d %+g  %s\n",
+			note.Ply+1, note.Side+1, note.Hole, note.Score, note.Mark,
+			note.Best.Move, note.Best.Score, note.Best.Notation)
+	}
+}
+
+// writeRecords saves records to a file, a blank line between each
+func writeRecords(path string, records []*record.Record) error {
+	var b strings.Builder
+	for i, r := range records {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if err := r.Write(&b); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.Flags().StringVar(&annotateMoves, "moves", "", "moves of a game in the game notation, instead of a record file")
+	annotateCmd.Flags().IntVar(&annotateGame, "game", 0, "game of the record file to annotate, 0 for all")
+	annotateCmd.Flags().IntVar(&annotateDepth, "depth", 10, "plies to search each move")
+	annotateCmd.Flags().StringVar(&annotateEvaluator, "evaluator", "store", "evaluator scoring positions at the depth, see players")
+	annotateCmd.Flags().IntVar(&annotateHash, "hash", 64, "megabytes of transposition table, 0 for none")
+	annotateCmd.Flags().Float64Var(&annotateThreshold, "threshold", 2, "score lost by a mistake, a blunder losing twice as much")
+	annotateCmd.Flags().StringVar(&annotateOutput, "output", "", "file to save the annotated records to")
+
+	viper.BindPFlag("annotate.game", annotateCmd.Flags().Lookup("game"))
+	viper.BindPFlag("annotate.depth", annotateCmd.Flags().Lookup("depth"))
+	viper.BindPFlag("annotate.evaluator", annotateCmd.Flags().Lookup("evaluator"))
+	viper.BindPFlag("annotate.hash", annotateCmd.Flags().Lookup("hash"))
+	viper.BindPFlag("annotate.threshold", annotateCmd.Flags().Lookup("threshold"))
+	viper.BindPFlag("annotate.output", annotateCmd.Flags().Lookup("output"))
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/book"
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/protocol"
+	"github.com/EFX-PXT1/mancala-go/pkg/record"
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+var bookDB string
+var bookGames []string
+var bookPlies int
+var bookDepth int
+var bookMargin float64
+var bookEvaluator string
+var bookHash int
+var bookMin int
+var bookPosition string
+
+// bookCmd builds an opening book
+var bookCmd = &cobra.Command{
+	Use:   "book",
+	Short: "Build an opening book",
+	Long: `Build an opening book of the width and stones, from deep analysis
+or from saved games, for computer players to play from with the book
+option. From analysis every move scoring within the margin of the best
+is followed to the plies, weighted 100 for the best down to 1 at the
+margin. From games each move is weighted by the times it was played and
+scored by the mean store margin. Building again adds to the book, the
+weights of a move being summed and its scores averaged by weight.
+For example:
+
+mconsole book --db book.db --plies 8 --depth 12 --margin 1
+mconsole book --db games.db --games games.txt --plies 10 --min 2
+mconsole match --p1 minimax --o1 book=book.db --p2 minimax`,
+	Run: func(cmd *cobra.Command, args []string) {
+		width, stones := viper.GetInt("game.width"), viper.GetInt("game.stones")
+		game.DefineGame(width, stones)
+		plies := viper.GetInt("book.plies")
+		var b *book.Book
+		if len(bookGames) > 0 {
+			var err error
+			if b, err = gamesBook(width, stones, plies); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+		} else {
+			name := viper.GetString("book.evaluator")
+			eval, err := game.LookupEvaluator(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			m := &search.Minimax{Depth: viper.GetInt("book.depth"), Eval: eval}
+			if mb := viper.GetInt("book.hash"); mb > 0 {
+				m.Table = search.NewTable(mb)
+			}
+			margin := viper.GetFloat64("book.margin")
+			b = book.New(width, stones, fmt.Sprintf("analysis depth %d evaluator %s margin %g", m.Depth, name, margin))
+			score := func(ctx context.Context, pos *game.Position) ([]book.Move, error) {
+				lines, err := m.Analyse(ctx, pos, 0)
+				if err != nil {
+					return nil, err
+				}
+				moves := make([]book.Move, len(lines))
+				for i, l := range lines {
+					moves[i] = book.Move{Hole: l.Move, Score: l.Score}
+				}
+				return moves, nil
+			}
+			err = b.Analyse(context.Background(), plies, margin, score, func(n int) {
+				fmt.Printf("\rpositions %d", n)
+			})
+			fmt.Println()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+		}
+
+		db := viper.GetString("book.db")
+		if err := b.Save(db); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		fmt.Printf("book %s  width %d stones %d  positions %d  %s\n", db, b.Width, b.Stones, b.Len(), b.Source)
+	},
+}
+
+// gamesBook builds a book from the records of the games files,
+// skipping those of another width and stones
+func gamesBook(width int, stones int, plies int) (*book.Book, error) {
+	b := book.New(width, stones, "")
+	games, skipped := 0, 0
+	for _, path := range bookGames {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		records, err := record.Read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for i, r := range records {
+			score, ok := recordScore(r)
+			if r.Width != width || r.Stones != stones || !ok {
+				skipped++
+				continue
+			}
+			if err := b.AddGame(r.Moves, plies, score); err != nil {
+				return nil, fmt.Errorf("%s: record %d: %v", path, i+1, err)
+			}
+			games++
+		}
+	}
+	if skipped > 0 {
+		fmt.Printf("skipped %d games of another width and stones or without a score\n", skipped)
+	}
+	b.Prune(viper.GetInt("book.min"))
+	b.Source = fmt.Sprintf("games %d min %d", games, viper.GetInt("book.min"))
+	return b, nil
+}
+
+// recordScore is the final store margin of the first player, from the
+// last position when the game was played out, otherwise its score tag
+func recordScore(r *record.Record) (int, bool) {
+	plies, err := r.Replay()
+	if err != nil {
+		return 0, false
+	}
+	if n := len(plies); n > 0 && plies[n-1].Transition.Result == game.EndOfGame {
+		last := plies[n-1]
+		score := last.Transition.Next.Score()
+		if last.Side == 1 {
+			score = -score
+		}
+		return score, true
+	}
+	score, err := strconv.Atoi(r.Tags["score"])
+	return score, err == nil
+}
+
+// bookShowCmd shows the moves of a position in a book
+var bookShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the book moves of a position",
+	Long: `Show the moves of a position in an opening book, with their
+weights and scores from the side to move. For example:
+
+mconsole book show --db book.db --position 0,4,4,4,4,4,4,0,4,4,4,4,4,4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db := viper.GetString("book.db")
+		b, err := book.Open(db)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		game.DefineGame(b.Width, b.Stones)
+		pos := game.StartPosition()
+		if bookPosition != "" {
+			if pos, err = protocol.ParsePosition(bookPosition); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+		}
+		fmt.Printf("book %s  width %d stones %d  positions %d  %s\n", db, b.Width, b.Stones, b.Len(), b.Source)
+		moves := b.Lookup(pos)
+		if moves == nil {
+			fmt.Printf("position %s is not in the book\n", pos.AsCsv())
+			return
+		}
+		pos.Show()
+		fmt.Printf("%4s  %6s  %8s\n", "hole", "weight", "score")
+		for _, m := range moves {
+			fmt.Printf("%4d  %6d  %+8.2f\n", m.Hole, m.Weight, m.Score)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bookCmd)
+	bookCmd.AddCommand(bookShowCmd)
+
+	bookCmd.PersistentFlags().StringVar(&bookDB, "db", "book.db", "directory of the book")
+	bookCmd.Flags().StringArrayVar(&bookGames, "games", nil, "record file of games to build from, instead of analysis")
+	bookCmd.Flags().IntVar(&bookPlies, "plies", 8, "moves from the start to cover")
+	bookCmd.Flags().IntVar(&bookDepth, "depth", 12, "plies to analyse each move")
+	bookCmd.Flags().Float64Var(&bookMargin, "margin", 1, "score below the best of moves to follow")
+	bookCmd.Flags().StringVar(&bookEvaluator, "evaluator", "store", "evaluator scoring positions at the depth, see players")
+	bookCmd.Flags().IntVar(&bookHash, "hash", 64, "megabytes of transposition table, 0 for none")
+	bookCmd.Flags().IntVar(&bookMin, "min", 1, "times a move of the games of this build must be played to be kept")
+	bookShowCmd.Flags().StringVar(&bookPosition, "position", "", "position csv from the side to move (default is the start)")
+
+	viper.BindPFlag("book.db", bookCmd.PersistentFlags().Lookup("db"))
+	viper.BindPFlag("book.plies", bookCmd.Flags().Lookup("plies"))
+	viper.BindPFlag("book.depth", bookCmd.Flags().Lookup("depth"))
+	viper.BindPFlag("book.margin", bookCmd.Flags().Lookup("margin"))
+	viper.BindPFlag("book.evaluator", bookCmd.Flags().Lookup("evaluator"))
+	viper.BindPFlag("book.hash", bookCmd.Flags().Lookup("hash"))
+	viper.BindPFlag("book.min", bookCmd.Flags().Lookup("min"))
+}
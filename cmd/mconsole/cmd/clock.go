@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// addClockFlags adds time control flags to a command, bound under section
+func addClockFlags(cmd *cobra.Command, section string) {
+	cmd.Flags().Duration("time", 0, "base time on each game clock, such as 5m, 0 for none")
+	cmd.Flags().Duration("inc", 0, "time added to a clock after each move")
+	cmd.Flags().Duration("movetime", 0, "time budget for each move, 0 for none")
+
+	viper.BindPFlag(section+".time", cmd.Flags().Lookup("time"))
+	viper.BindPFlag(section+".increment", cmd.Flags().Lookup("inc"))
+	viper.BindPFlag(section+".movetime", cmd.Flags().Lookup("movetime"))
+}
+
+// timeControl reads the time control of a section
+func timeControl(section string) game.TimeControl {
+	return game.TimeControl{
+		Base:      viper.GetDuration(section + ".time"),
+		Increment: viper.GetDuration(section + ".increment"),
+		MoveTime:  viper.GetDuration(section + ".movetime"),
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/protocol"
+)
+
+var enginePlayerType string
+var engineOptions []string
+
+// engineCmd serves the engine protocol on stdin and stdout
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Serve the engine protocol on stdin and stdout",
+	Long: `Serve the engine protocol on stdin and stdout
+so a GUI or a script in another language can use the Go engine.
+Besides the protocol commands the engine answers
+
+moves             valid moves, as moves 1 2 3
+move <hole>       play a move, as moved <hole> <result> <csv>
+show              the position, as position <csv>
+player [<type> [name=value...]]
+                  change the player type giving the best move
+position startpos|<csv> [moves <hole>...]
+
+Best moves come from the player type, seeded so that the same
+commands always give the same responses as long as go is given
+no movetime, a search against the clock depending on the machine.
+Failures are answered with error <message>, commands which are
+not understood being ignored.
+For example:
+
+mconsole engine --type random`,
+	Run: func(cmd *cobra.Command, args []string) {
+		game.DefineGame(
+			viper.GetInt("game.width"),
+			viper.GetInt("game.stones"),
+		)
+		s, err := protocol.NewServer("mancala-go", engineConf,
+			viper.GetString("engine.type"), engineOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		defer s.Close()
+		if err = s.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	},
+}
+
+// engineConf configures a served player, quiet
+// and seeded so that responses are repeatable
+func engineConf(playerType string, options []string) (map[string]string, error) {
+	t, err := game.LookupPlayerType(playerType)
+	if err != nil {
+		return nil, err
+	}
+	conf := map[string]string{}
+	if t.HasParam("quiet") {
+		conf["quiet"] = "true"
+	}
+	if t.HasParam("seed") {
+		conf["seed"] = "1"
+	}
+	pc, err := playerConf(playerType, "", options)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range pc {
+		conf[k] = v
+	}
+	return conf, nil
+}
+
+func init() {
+	rootCmd.AddCommand(engineCmd)
+
+	engineCmd.Flags().StringVarP(&enginePlayerType, "type", "t", "random", "player type giving the best move")
+	engineCmd.Flags().StringArrayVarP(&engineOptions, "option", "o", nil, "player option as name=value, see players")
+
+	viper.BindPFlag("engine.type", engineCmd.Flags().Lookup("type"))
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/match"
+	"github.com/EFX-PXT1/mancala-go/pkg/record"
+	// register the search player types
+	_ "github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+var matchP1 string
+var matchP2 string
+var matchOptions1 []string
+var matchOptions2 []string
+var matchGames int
+var matchSeed int64
+var matchRecord string
+
+// matchCmd plays many games between two player types
+var matchCmd = &cobra.Command{
+	Use:   "match",
+	Short: "Play many games between two player types",
+	Long: `Play many games between two player types without showing the board.
+The players alternate moving first, player types accepting a seed
+being seeded from --seed so a match can be repeated.
+Results are from the side of p1. For example:
+
+mconsole match --p1 minimax --p2 random --games 1000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		game.DefineGame(
+			viper.GetInt("game.width"),
+			viper.GetInt("game.stones"),
+		)
+		p1, err := playerConf(viper.GetString("match.p1"), "", matchOptions1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		p2, err := playerConf(viper.GetString("match.p2"), "", matchOptions2)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		conf := match.Config{
+			P1:    p1,
+			P2:    p2,
+			Games: viper.GetInt("match.games"),
+			Seed:  viper.GetInt64("match.seed"),
+			Clock: timeControl("match"),
+		}
+		played := 0
+		games, err := match.Run(context.Background(), conf, func(g match.Game) {
+			played++
+			fmt.Fprintf(os.Stderr, "\r%d", played)
+		})
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		match.Summarise(games).Write(os.Stdout, "p1 "+label(p1), "p2 "+label(p2))
+
+		if path := viper.GetString("match.record"); path != "" {
+			labels := [2]string{label(p1), label(p2)}
+			records := make([]*record.Record, len(games))
+			for i, g := range games {
+				r := record.New(game.WIDTH(), game.STONE())
+				r.Moves = g.Moves
+				r.Tags["game"] = strconv.Itoa(i + 1)
+				r.Tags["p1"] = labels[g.First]
+				r.Tags["p2"] = labels[1-g.First]
+				r.SetResult(g.Result)
+				records[i] = r
+			}
+			if err := writeRecords(path, records); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+		}
+	},
+}
+
+// label is the name of a player, or its type
+func label(conf map[string]string) string {
+	if conf["name"] != "" {
+		return conf["name"]
+	}
+	return conf["type"]
+}
+
+func init() {
+	rootCmd.AddCommand(matchCmd)
+
+	matchCmd.Flags().StringVar(&matchP1, "p1", "minimax", "player type of p1")
+	matchCmd.Flags().StringVar(&matchP2, "p2", "random", "player type of p2")
+	matchCmd.Flags().StringArrayVar(&matchOptions1, "o1", nil, "p1 option as name=value, see players")
+	matchCmd.Flags().StringArrayVar(&matchOptions2, "o2", nil, "p2 option as name=value, see players")
+	matchCmd.Flags().IntVarP(&matchGames, "games", "g", 100, "games to play")
+	matchCmd.Flags().Int64Var(&matchSeed, "seed", 1, "random seed")
+	matchCmd.Flags().StringVar(&matchRecord, "record", "", "file to save the games to, see annotate")
+
+	viper.BindPFlag("match.p1", matchCmd.Flags().Lookup("p1"))
+	viper.BindPFlag("match.p2", matchCmd.Flags().Lookup("p2"))
+	viper.BindPFlag("match.games", matchCmd.Flags().Lookup("games"))
+	viper.BindPFlag("match.seed", matchCmd.Flags().Lookup("seed"))
+	viper.BindPFlag("match.record", matchCmd.Flags().Lookup("record"))
+	addClockFlags(matchCmd, "match")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// playersCmd lists the player types and their options
+var playersCmd = &cobra.Command{
+	Use:   "players",
+	Short: "List player types and their options",
+	Long: `List player types and their options.
+Options are given with --option name=value or in a
+block for the player type in the config file, for example:
+
+players:
+  random:
+    seed: 42
+
+Evaluators weighting the built-in evaluators can also be named:
+
+evaluators:
+  balanced:
+    store: 1
+    mobility: 0.25`,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, t := range game.PlayerTypes() {
+			fmt.Printf("%s - %s\n", t.Name, t.Description)
+			for _, p := range t.AllParams() {
+				detail := p.Description
+				if p.Default != "" {
+					detail += fmt.Sprintf(" (default %s)", p.Default)
+				}
+				if r := p.Range(); r != "" {
+					detail += fmt.Sprintf(" [%s]", r)
+				}
+				fmt.Printf("  %-10s %-6s %s\n", p.Name, p.Type, detail)
+			}
+		}
+		fmt.Printf("evaluators - %s\n", strings.Join(game.Evaluators(), ", "))
+	},
+}
+
+// playerConf creates the configuration for a player type from its
+// block in the config file, overridden by name=value options
+func playerConf(playerType string, name string, options []string) (map[string]string, error) {
+	conf := map[string]string{}
+	for k, v := range viper.GetStringMapString("players." + playerType) {
+		conf[k] = v
+	}
+	conf["type"] = playerType
+	if name != "" {
+		conf["name"] = name
+	}
+	for _, o := range options {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("option %q must be name=value", o)
+		}
+		conf[kv[0]] = kv[1]
+	}
+	return conf, nil
+}
+
+func init() {
+	rootCmd.AddCommand(playersCmd)
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"strconv"
@@ -11,7 +14,11 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/EFX-PXT1/mancala-go/cmd/internal/weights"
 	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/record"
+	// register the external player type
+	_ "github.com/EFX-PXT1/mancala-go/pkg/protocol"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
@@ -24,6 +31,8 @@ var repl bool
 var showDelta bool
 var playerType string
 var playerName string
+var playerOptions []string
+var recordFile string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -34,6 +43,8 @@ enables development and playing positions.
 For example:
 
 mconsole --width <width> --stones <start stones> ...moves`,
+	// moves rather than sub commands
+	Args: cobra.ArbitraryArgs,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
@@ -47,6 +58,16 @@ mconsole --width <width> --stones <start stones> ...moves`,
 
 		// record history for diagnostics
 		history := make([]string, 0)
+		// side to move, 0 for the first player
+		side := 0
+		// player of both sides, and the result once over
+		player := "console"
+		var result *game.GameResult
+		defer func() {
+			if path := viper.GetString("record"); path != "" {
+				saveGame(path, history, player, result)
+			}
+		}()
 
 		// process arg turns
 		var x string
@@ -63,6 +84,7 @@ mconsole --width <width> --stones <start stones> ...moves`,
 					}
 					if mr == game.EndOfTurn {
 						pos = pos.ChangePlayer()
+						side = 1 - side
 					}
 					pos.Show()
 					if valid, delta := pos.IsValid(); !valid {
@@ -75,6 +97,7 @@ mconsole --width <width> --stones <start stones> ...moves`,
 				}
 				if mr == game.EndOfGame {
 					fmt.Printf("*** Game Over ***\n")
+					result = gameOver(pos, side)
 					return
 				}
 			}
@@ -96,6 +119,7 @@ mconsole --width <width> --stones <start stones> ...moves`,
 						}
 						if mr == game.EndOfTurn {
 							pos = pos.ChangePlayer()
+							side = 1 - side
 						}
 						pos.Show()
 						if valid, delta := pos.IsValid(); !valid {
@@ -108,6 +132,7 @@ mconsole --width <width> --stones <start stones> ...moves`,
 					}
 					if mr == game.EndOfGame {
 						fmt.Printf("*** Game Over ***\n")
+						result = gameOver(pos, side)
 						return
 					}
 				}
@@ -116,17 +141,54 @@ mconsole --width <width> --stones <start stones> ...moves`,
 			// seed rand
 			rand.Seed(time.Now().UTC().UnixNano())
 			// configure players
-			conf := map[string]string{
-				"type": viper.GetString("player.type"),
-				"name": viper.GetString("player.name"),
+			conf, err := playerConf(
+				viper.GetString("player.type"),
+				viper.GetString("player.name"),
+				playerOptions,
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
 			}
-			player, err := game.CreatePlayer(conf)
+			agent, err := game.CreateAgent(conf)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				return
 			}
+			player = label(conf)
+			if c, ok := agent.(io.Closer); ok {
+				defer c.Close()
+			}
+			var clocks *game.Clocks
+			if tc := timeControl("clock"); tc != (game.TimeControl{}) {
+				clocks = game.NewClocks(tc)
+			}
 			for {
-				hole := player.Move(pos)
+				ctx, cancel := context.Background(), context.CancelFunc(func() {})
+				if clocks != nil {
+					ctx = game.WithClock(ctx, clocks.Clock(side))
+					if limit := clocks.Limit(side); limit > 0 {
+						ctx, cancel = context.WithTimeout(ctx, limit)
+					}
+				}
+				began := time.Now()
+				hole, err := agent.Move(ctx, pos)
+				cancel()
+				if clocks != nil && !clocks.Used(side, time.Since(began)) {
+					fmt.Printf("\n*** Out of Time ***\n")
+					fmt.Println(clocks)
+					result = &game.GameResult{Winner: 1 - side, TimeOut: true}
+					return
+				}
+				if errors.Is(err, game.ErrResign) {
+					fmt.Printf("*** Resigned ***\n")
+					result = &game.GameResult{Winner: 1 - side, Resigned: true}
+					return
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\nerror: %v\n", err)
+					return
+				}
 				var delta *game.Position
 				var mr game.MoveResult
 				if pos, delta, mr, err = pos.Move(hole); err == nil {
@@ -136,8 +198,12 @@ mconsole --width <width> --stones <start stones> ...moves`,
 					}
 					if mr == game.EndOfTurn {
 						pos = pos.ChangePlayer()
+						side = 1 - side
 					}
 					pos.Show()
+					if clocks != nil {
+						fmt.Println(clocks)
+					}
 					if valid, delta := pos.IsValid(); !valid {
 						fmt.Fprintf(os.Stderr, "POSITION CORRUPT BY LAST MOVE [%d]\n", delta)
 						fmt.Fprintf(os.Stderr, "%s\n", strings.Join(history, " "))
@@ -148,6 +214,7 @@ mconsole --width <width> --stones <start stones> ...moves`,
 				}
 				if mr == game.EndOfGame {
 					fmt.Printf("*** Game Over ***\n")
+					result = gameOver(pos, side)
 					return
 				}
 			}
@@ -155,6 +222,37 @@ mconsole --width <width> --stones <start stones> ...moves`,
 	},
 }
 
+// gameOver is the result of a game ending on a move of side
+func gameOver(pos *game.Position, side int) *game.GameResult {
+	if side == 1 {
+		pos = pos.ChangePlayer()
+	}
+	result := &game.GameResult{Final: pos, Score: pos.Score(), Winner: -1}
+	switch {
+	case result.Score > 0:
+		result.Winner = 0
+	case result.Score < 0:
+		result.Winner = 1
+	}
+	return result
+}
+
+// saveGame writes the moves played to a record file, see annotate
+func saveGame(path string, history []string, player string, result *game.GameResult) {
+	r := record.New(game.WIDTH(), game.STONE())
+	for _, x := range history {
+		hole, _ := strconv.Atoi(x)
+		r.Moves = append(r.Moves, hole)
+	}
+	r.Tags["p1"], r.Tags["p2"] = player, player
+	if result != nil {
+		r.SetResult(result)
+	}
+	if err := writeRecords(path, []*record.Record{r}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -171,22 +269,27 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.mancala.yaml)")
+	weights.AddFlag(rootCmd)
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	rootCmd.Flags().IntVarP(&width, "width", "w", 6, "width of board")
-	rootCmd.Flags().IntVarP(&stones, "stones", "s", 4, "intial number of stones")
+	rootCmd.PersistentFlags().IntVarP(&width, "width", "w", 6, "width of board")
+	rootCmd.PersistentFlags().IntVarP(&stones, "stones", "s", 4, "intial number of stones")
 	rootCmd.Flags().BoolVarP(&repl, "repl", "r", false, "enter REPL")
 	rootCmd.Flags().BoolVar(&showDelta, "delta", false, "show delta position")
 	rootCmd.Flags().StringVarP(&playerType, "type", "t", "console", "player type")
-	rootCmd.Flags().StringVarP(&playerName, "name", "n", "console", "player name")
+	rootCmd.Flags().StringVarP(&playerName, "name", "n", "", "player name (default is the type)")
+	rootCmd.Flags().StringVar(&recordFile, "record", "", "file to save the game to, see annotate")
+	rootCmd.Flags().StringArrayVarP(&playerOptions, "option", "o", nil, "player option as name=value, see players")
 
-	viper.BindPFlag("game.width", rootCmd.Flags().Lookup("width"))
-	viper.BindPFlag("game.stones", rootCmd.Flags().Lookup("stones"))
+	viper.BindPFlag("game.width", rootCmd.PersistentFlags().Lookup("width"))
+	viper.BindPFlag("game.stones", rootCmd.PersistentFlags().Lookup("stones"))
 	viper.BindPFlag("repl", rootCmd.Flags().Lookup("repl"))
 	viper.BindPFlag("show.delta", rootCmd.Flags().Lookup("delta"))
 	viper.BindPFlag("player.type", rootCmd.Flags().Lookup("type"))
 	viper.BindPFlag("player.name", rootCmd.Flags().Lookup("name"))
+	viper.BindPFlag("record", rootCmd.Flags().Lookup("record"))
+	addClockFlags(rootCmd, "clock")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -213,4 +316,17 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		//	fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	weights.Register()
+}
+
+// defineGame defines the game of the width and stones flags,
+// which need at least a hole and a stone to play from random openings
+func defineGame() error {
+	width, stones := viper.GetInt("game.width"), viper.GetInt("game.stones")
+	if width < 1 || stones < 1 {
+		return fmt.Errorf("width %d and stones %d must be at least 1", width, stones)
+	}
+	game.DefineGame(width, stones)
+	return nil
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/match"
+)
+
+var sprtCandidate string
+var sprtBaseline string
+var sprtCandidateOptions []string
+var sprtBaselineOptions []string
+var sprtElo0 float64
+var sprtElo1 float64
+var sprtAlpha float64
+var sprtBeta float64
+var sprtOpening int
+var sprtMaxGames int
+var sprtReport int
+var sprtSeed int64
+
+// sprtCmd tests if a candidate is stronger than a baseline
+var sprtCmd = &cobra.Command{
+	Use:   "sprt",
+	Short: "Test if a candidate is stronger than a baseline",
+	Long: `Test if a candidate player is stronger than a baseline with a
+sequential probability ratio test, stopping as soon as it can accept
+H0, the candidate is no stronger than elo0, or H1, it is stronger by elo1.
+Pairs of games are played from random openings, each player moving
+first from the opening once. For example:
+
+mconsole match sprt --candidate minimax --oc depth=5 --baseline minimax --ob depth=4 --elo0 0 --elo1 20`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := defineGame(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		candidate, err := playerConf(viper.GetString("sprt.candidate"), "", sprtCandidateOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		baseline, err := playerConf(viper.GetString("sprt.baseline"), "", sprtBaselineOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		conf := match.SPRTConfig{
+			Candidate:    candidate,
+			Baseline:     baseline,
+			Elo0:         viper.GetFloat64("sprt.elo0"),
+			Elo1:         viper.GetFloat64("sprt.elo1"),
+			Alpha:        viper.GetFloat64("sprt.alpha"),
+			Beta:         viper.GetFloat64("sprt.beta"),
+			OpeningPlies: viper.GetInt("sprt.opening"),
+			MaxGames:     viper.GetInt("sprt.maxgames"),
+			Seed:         viper.GetInt64("sprt.seed"),
+		}
+		report := viper.GetInt("sprt.report")
+		s, err := match.RunSPRT(context.Background(), conf, func(s *match.SPRTState) {
+			if report > 0 && s.Games%report == 0 {
+				writeSPRT(s)
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		if report == 0 || s.Games%report != 0 {
+			writeSPRT(s)
+		}
+		fmt.Printf("%s after %d games\n", s.Decision, s.Games)
+	},
+}
+
+// writeSPRT shows the progress of a test
+func writeSPRT(s *match.SPRTState) {
+	fmt.Printf("games %6d  W-D-L %d-%d-%d  elo %+.1f [%+.1f, %+.1f]  LLR %+.3f [%.3f, %.3f]\n",
+		s.Games, s.Wins, s.Draws, s.Losses, s.Elo.Mean, s.Elo.Low, s.Elo.High, s.LLR, s.Lower, s.Upper)
+}
+
+func init() {
+	matchCmd.AddCommand(sprtCmd)
+
+	sprtCmd.Flags().StringVar(&sprtCandidate, "candidate", "minimax", "player type of the candidate")
+	sprtCmd.Flags().StringVar(&sprtBaseline, "baseline", "minimax", "player type of the baseline")
+	sprtCmd.Flags().StringArrayVar(&sprtCandidateOptions, "oc", nil, "candidate option as name=value, see players")
+	sprtCmd.Flags().StringArrayVar(&sprtBaselineOptions, "ob", nil, "baseline option as name=value, see players")
+	sprtCmd.Flags().Float64Var(&sprtElo0, "elo0", 0, "elo difference of H0")
+	sprtCmd.Flags().Float64Var(&sprtElo1, "elo1", 10, "elo difference of H1")
+	sprtCmd.Flags().Float64Var(&sprtAlpha, "alpha", 0.05, "chance of accepting H1 when H0 is true")
+	sprtCmd.Flags().Float64Var(&sprtBeta, "beta", 0.05, "chance of accepting H0 when H1 is true")
+	sprtCmd.Flags().IntVar(&sprtOpening, "opening", 4, "random moves in each opening")
+	sprtCmd.Flags().IntVar(&sprtMaxGames, "max-games", 20000, "games before giving up, 0 for no limit")
+	sprtCmd.Flags().IntVar(&sprtReport, "report", 100, "games between progress reports, 0 for none")
+	sprtCmd.Flags().Int64Var(&sprtSeed, "seed", 1, "random seed")
+
+	viper.BindPFlag("sprt.candidate", sprtCmd.Flags().Lookup("candidate"))
+	viper.BindPFlag("sprt.baseline", sprtCmd.Flags().Lookup("baseline"))
+	viper.BindPFlag("sprt.elo0", sprtCmd.Flags().Lookup("elo0"))
+	viper.BindPFlag("sprt.elo1", sprtCmd.Flags().Lookup("elo1"))
+	viper.BindPFlag("sprt.alpha", sprtCmd.Flags().Lookup("alpha"))
+	viper.BindPFlag("sprt.beta", sprtCmd.Flags().Lookup("beta"))
+	viper.BindPFlag("sprt.opening", sprtCmd.Flags().Lookup("opening"))
+	viper.BindPFlag("sprt.maxgames", sprtCmd.Flags().Lookup("max-games"))
+	viper.BindPFlag("sprt.report", sprtCmd.Flags().Lookup("report"))
+	viper.BindPFlag("sprt.seed", sprtCmd.Flags().Lookup("seed"))
+}
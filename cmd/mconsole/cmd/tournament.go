@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/match"
+)
+
+var tournamentPlayers []string
+var tournamentFormat string
+var tournamentRounds int
+var tournamentGames int
+var tournamentSeed int64
+var tournamentDB string
+var ratingsPlayer string
+
+// tournamentCmd plays a tournament between configured players
+var tournamentCmd = &cobra.Command{
+	Use:   "tournament",
+	Short: "Play a tournament between configured players",
+	Long: `Play a round robin or Swiss tournament between configured players
+storing the games so Elo ratings accumulate over many tournaments.
+Players are named configurations given with --player, or otherwise
+taken from the config file:
+
+tournament:
+  players:
+    deep:
+      type: minimax
+      depth: 8
+    rnd:
+      type: random
+
+For example:
+
+mconsole tournament --player name=d4,type=minimax,depth=4 --player name=rnd,type=random`,
+	Run: func(cmd *cobra.Command, args []string) {
+		game.DefineGame(
+			viper.GetInt("game.width"),
+			viper.GetInt("game.stones"),
+		)
+		format, err := match.ParseFormat(viper.GetString("tournament.format"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		entrants, err := tournamentEntrants()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		var store *match.Store
+		if db := viper.GetString("tournament.db"); db != "" {
+			if store, err = match.OpenStore(db); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			defer store.Close()
+		}
+
+		conf := match.TournamentConfig{
+			Entrants: entrants,
+			Format:   format,
+			Rounds:   viper.GetInt("tournament.rounds"),
+			Games:    viper.GetInt("tournament.games"),
+			Seed:     viper.GetInt64("tournament.seed"),
+			Clock:    timeControl("tournament"),
+		}
+		played := 0
+		games, standings, err := match.RunTournament(context.Background(), conf, store, func(g *match.GameRecord) {
+			played++
+			fmt.Fprintf(os.Stderr, "\r%d", played)
+		})
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("%-4s %-16s %7s %6s\n", "rank", "name", "points", "games")
+		for i, s := range standings {
+			fmt.Printf("%-4d %-16s %7.1f %6d\n", i+1, s.Name, s.Points, s.Games)
+		}
+		fmt.Println()
+
+		// ratings over every stored game, or just this tournament
+		var ratings []match.Rating
+		if store != nil {
+			if ratings, err = store.Ratings(game.WIDTH(), game.STONE()); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+		} else {
+			outcomes := make([]match.Outcome, len(games))
+			for i, g := range games {
+				outcomes[i] = g.Outcome()
+			}
+			ratings = match.Ratings(outcomes)
+		}
+		writeRatings(os.Stdout, ratings)
+	},
+}
+
+// ratingsCmd shows the ratings in a tournament store
+var ratingsCmd = &cobra.Command{
+	Use:   "ratings",
+	Short: "Show the Elo ratings of stored tournaments",
+	Long: `Show the Elo ratings of every stored game of the width and stones,
+or a single player with its configuration and results by opponent.
+For example:
+
+mconsole tournament ratings --player d4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		width, stones := viper.GetInt("game.width"), viper.GetInt("game.stones")
+		store, err := match.OpenStore(viper.GetString("tournament.db"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		defer store.Close()
+
+		ratings, err := store.Ratings(width, stones)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		if ratingsPlayer == "" {
+			writeRatings(os.Stdout, ratings)
+			return
+		}
+
+		p, err := store.Player(ratingsPlayer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: player %s: %v\n", ratingsPlayer, err)
+			return
+		}
+		fmt.Printf("%s %s\n", p.Name, formatConf(p.Conf))
+		for _, r := range ratings {
+			if r.Name == p.Name {
+				fmt.Printf("elo %+.0f ± %.0f from %d games\n", r.Elo, r.Error, r.Games)
+			}
+		}
+		games, err := store.Games(width, stones, p.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		writeResults(os.Stdout, p.Name, games)
+	},
+}
+
+// tournamentEntrants are the players given with --player,
+// otherwise those of the config file in name order
+func tournamentEntrants() ([]match.Entrant, error) {
+	var entrants []match.Entrant
+	for _, spec := range tournamentPlayers {
+		conf := map[string]string{}
+		for _, o := range strings.Split(spec, ",") {
+			kv := strings.SplitN(o, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return nil, fmt.Errorf("player %q: option %q must be name=value", spec, o)
+			}
+			conf[kv[0]] = kv[1]
+		}
+		if conf["name"] == "" || conf["type"] == "" {
+			return nil, fmt.Errorf("player %q: name and type are required", spec)
+		}
+		entrants = append(entrants, match.Entrant{Name: conf["name"], Conf: conf})
+	}
+	if len(entrants) > 0 {
+		return entrants, nil
+	}
+
+	names := make([]string, 0)
+	for name := range viper.GetStringMap("tournament.players") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conf := viper.GetStringMapString("tournament.players." + name)
+		conf["name"] = name
+		if conf["type"] == "" {
+			return nil, fmt.Errorf("player %s: type is required", name)
+		}
+		entrants = append(entrants, match.Entrant{Name: name, Conf: conf})
+	}
+	return entrants, nil
+}
+
+// formatConf lists options in name order
+func formatConf(conf map[string]string) string {
+	keys := make([]string, 0, len(conf))
+	for k := range conf {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = k + "=" + conf[k]
+	}
+	return strings.Join(s, ",")
+}
+
+// writeRatings as a table, best first, the error
+// being the half width of a 95% confidence interval
+func writeRatings(w io.Writer, ratings []match.Rating) {
+	fmt.Fprintf(w, "%-4s %-16s %6s %6s %6s %7s\n", "rank", "name", "elo", "error", "games", "score")
+	for i, r := range ratings {
+		fmt.Fprintf(w, "%-4d %-16s %+6.0f %6.0f %6d %7.1f\n", i+1, r.Name, r.Elo, r.Error, r.Games, r.Score)
+	}
+}
+
+// writeResults of a player by opponent
+func writeResults(w io.Writer, name string, games []*match.GameRecord) {
+	type result struct{ wins, draws, losses int }
+	results := make(map[string]*result)
+	var opponents []string
+	for _, g := range games {
+		o := g.Outcome()
+		opp, score := o.B, o.Score
+		if o.B == name {
+			opp, score = o.A, 1-o.Score
+		}
+		r, ok := results[opp]
+		if !ok {
+			r = &result{}
+			results[opp] = r
+			opponents = append(opponents, opp)
+		}
+		switch score {
+		case 1:
+			r.wins++
+		case 0:
+			r.losses++
+		default:
+			r.draws++
+		}
+	}
+	sort.Strings(opponents)
+	fmt.Fprintf(w, "%-16s %5s %5s %6s\n", "opponent", "wins", "draws", "losses")
+	for _, opp := range opponents {
+		r := results[opp]
+		fmt.Fprintf(w, "%-16s %5d %5d %6d\n", opp, r.wins, r.draws, r.losses)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(tournamentCmd)
+	tournamentCmd.AddCommand(ratingsCmd)
+
+	tournamentCmd.PersistentFlags().StringVar(&tournamentDB, "db", "tournament.db", "directory of the tournament store, empty for none")
+	tournamentCmd.Flags().StringArrayVar(&tournamentPlayers, "player", nil, "player as name=<name>,type=<type>,<option>=<value>...")
+	tournamentCmd.Flags().StringVar(&tournamentFormat, "format", "roundrobin", "format of <roundrobin|swiss>")
+	tournamentCmd.Flags().IntVar(&tournamentRounds, "rounds", 0, "rounds of a swiss tournament (default enough to find a winner)")
+	tournamentCmd.Flags().IntVarP(&tournamentGames, "games", "g", 2, "games each pairing plays")
+	tournamentCmd.Flags().Int64Var(&tournamentSeed, "seed", 1, "random seed")
+	ratingsCmd.Flags().StringVar(&ratingsPlayer, "player", "", "show a single player")
+
+	viper.BindPFlag("tournament.db", tournamentCmd.PersistentFlags().Lookup("db"))
+	viper.BindPFlag("tournament.format", tournamentCmd.Flags().Lookup("format"))
+	viper.BindPFlag("tournament.rounds", tournamentCmd.Flags().Lookup("rounds"))
+	viper.BindPFlag("tournament.games", tournamentCmd.Flags().Lookup("games"))
+	viper.BindPFlag("tournament.seed", tournamentCmd.Flags().Lookup("seed"))
+	addClockFlags(tournamentCmd, "tournament")
+}
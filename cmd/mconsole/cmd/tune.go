@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/tune"
+)
+
+var tuneStart string
+var tuneTerms []string
+var tuneDepth int
+var tuneIterations int
+var tunePairs int
+var tuneStep float64
+var tunePerturbation float64
+var tuneOpening int
+var tuneWorkers int
+var tuneReport int
+var tuneSeed int64
+var tuneName string
+var tuneOutput string
+
+// tuneCmd optimises evaluator weights by self-play
+var tuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Tune evaluator weights by self-play",
+	Long: `Tune the weights of an evaluator by self-play using SPSA,
+simultaneous perturbation stochastic approximation. Each iteration
+minimax players with the weights nudged in opposite directions play
+pairs of games from random openings, in parallel, and the weights step
+towards the winner. Terms of the starting evaluator not being tuned
+are fixed, store anchoring the scale. The tuned weights are written as
+a named evaluator to load with --weights or paste into the config file.
+For example:
+
+mconsole tune --evaluator store --terms captures,mobility,repeats --depth 4 --output tuned.yaml
+mconsole --weights tuned.yaml match --o1 evaluator=tuned --p2 minimax --o2 depth=4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := defineGame(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		start, err := startWeights(viper.GetString("tune.evaluator"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		conf := tune.Config{
+			Start:        start,
+			Terms:        viper.GetStringSlice("tune.terms"),
+			Depth:        viper.GetInt("tune.depth"),
+			Iterations:   viper.GetInt("tune.iterations"),
+			Pairs:        viper.GetInt("tune.pairs"),
+			Step:         viper.GetFloat64("tune.step"),
+			Perturbation: viper.GetFloat64("tune.perturbation"),
+			OpeningPlies: viper.GetInt("tune.opening"),
+			Workers:      viper.GetInt("tune.workers"),
+			Seed:         viper.GetInt64("tune.seed"),
+		}
+		report := viper.GetInt("tune.report")
+		weights, err := tune.SPSA(context.Background(), conf, func(s tune.Step) {
+			if report > 0 && (s.Iteration%report == 0 || s.Iteration == conf.Iterations) {
+				fmt.Printf("iteration %5d  score %+.3f  %s\n", s.Iteration, s.Score, formatWeights(s.Weights))
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		name, output := viper.GetString("tune.name"), viper.GetString("tune.output")
+		if output == "-" {
+			if err := tune.WriteYAML(os.Stdout, name, weights); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+			return
+		}
+		if err := writeWeights(output, name, weights); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		fmt.Printf("evaluator %s written to %s\n", name, output)
+	},
+}
+
+// writeWeights saves a named evaluator to a file,
+// failing if it could not be closed
+func writeWeights(path string, name string, weights map[string]float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tune.WriteYAML(f, name, weights); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// startWeights are those of a weighted evaluator,
+// or weight one for a built-in
+func startWeights(name string) (map[string]float64, error) {
+	e, err := game.LookupEvaluator(name)
+	if err != nil {
+		return nil, err
+	}
+	if w, ok := e.(*game.Weighted); ok {
+		return w.Weights(), nil
+	}
+	return map[string]float64{name: 1}, nil
+}
+
+// formatWeights lists weights by term
+func formatWeights(weights map[string]float64) string {
+	terms := make([]string, 0, len(weights))
+	for term := range weights {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	for i, term := range terms {
+		terms[i] = fmt.Sprintf("%s=%.3f", term, weights[term])
+	}
+	return strings.Join(terms, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(tuneCmd)
+
+	tuneCmd.Flags().StringVar(&tuneStart, "evaluator", "store", "evaluator giving the starting weights, see players")
+	tuneCmd.Flags().StringSliceVar(&tuneTerms, "terms", []string{"captures", "material", "mobility", "repeats"}, "terms to tune, starting at zero if not weighted")
+	tuneCmd.Flags().IntVar(&tuneDepth, "depth", 4, "depth of the minimax players")
+	tuneCmd.Flags().IntVar(&tuneIterations, "iterations", 200, "iterations of the optimiser")
+	tuneCmd.Flags().IntVar(&tunePairs, "pairs", 8, "pairs of games each iteration")
+	tuneCmd.Flags().Float64Var(&tuneStep, "step", 1, "step size of the first iteration")
+	tuneCmd.Flags().Float64Var(&tunePerturbation, "perturbation", 0.5, "perturbation of the weights in the first iteration")
+	tuneCmd.Flags().IntVar(&tuneOpening, "opening", 4, "random moves in each opening")
+	tuneCmd.Flags().IntVar(&tuneWorkers, "workers", 0, "games played in parallel (default is one per CPU)")
+	tuneCmd.Flags().IntVar(&tuneReport, "report", 10, "iterations between progress reports, 0 for none")
+	tuneCmd.Flags().Int64Var(&tuneSeed, "seed", 1, "random seed")
+	tuneCmd.Flags().StringVar(&tuneName, "name", "tuned", "name of the tuned evaluator")
+	tuneCmd.Flags().StringVar(&tuneOutput, "output", "tuned.yaml", "file to write the weights, - for stdout")
+
+	viper.BindPFlag("tune.evaluator", tuneCmd.Flags().Lookup("evaluator"))
+	viper.BindPFlag("tune.terms", tuneCmd.Flags().Lookup("terms"))
+	viper.BindPFlag("tune.depth", tuneCmd.Flags().Lookup("depth"))
+	viper.BindPFlag("tune.iterations", tuneCmd.Flags().Lookup("iterations"))
+	viper.BindPFlag("tune.pairs", tuneCmd.Flags().Lookup("pairs"))
+	viper.BindPFlag("tune.step", tuneCmd.Flags().Lookup("step"))
+	viper.BindPFlag("tune.perturbation", tuneCmd.Flags().Lookup("perturbation"))
+	viper.BindPFlag("tune.opening", tuneCmd.Flags().Lookup("opening"))
+	viper.BindPFlag("tune.workers", tuneCmd.Flags().Lookup("workers"))
+	viper.BindPFlag("tune.report", tuneCmd.Flags().Lookup("report"))
+	viper.BindPFlag("tune.seed", tuneCmd.Flags().Lookup("seed"))
+	viper.BindPFlag("tune.name", tuneCmd.Flags().Lookup("name"))
+	viper.BindPFlag("tune.output", tuneCmd.Flags().Lookup("output"))
+}
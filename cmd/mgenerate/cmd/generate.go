@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+)
+
+// generator walks every transition reachable from a start position
+type generator struct {
+	out   posfile.Writer
+	stats *stats
+
+	// maxDepth limits the plies from the start
+	maxDepth int
+	// maxPositions limits the positions recorded
+	maxPositions int
+	// minStones prunes positions with fewer stones on the board,
+	// stones never return to the board so nothing below is lost
+	minStones int
+	// maxStones skips recording positions with more stones on the board,
+	// they are still walked to reach those within the limit
+	maxStones int
+}
+
+// run generates breadth first, each pass of todo being one ply deeper
+func (g *generator) run(p *game.Position) error {
+	// initial seed position and moves
+	set := make(map[string]bool) // empty set of processed or to process
+	g.seed(set, p)
+
+	count := 0
+
+	// create a todo slice
+	todo := toGenerate(set)
+
+	for depth := 0; len(todo) > 0; depth++ {
+		if g.maxDepth > 0 && depth >= g.maxDepth {
+			break
+		}
+		for _, k := range todo {
+			// evaluate this single todo move
+			s := strings.Split(k, ";")
+			p = game.CreatePositionCsv(s[0])
+			move, _ := strconv.Atoi(s[1])
+			set[k] = true
+			t, _ := p.Play(move)
+			e, result := t.Next, t.Result
+			if g.record(p) {
+				if g.maxPositions > 0 && g.stats.Positions >= g.maxPositions && !g.stats.counted(p) {
+					return nil
+				}
+				rec := &posfile.Record{Position: p, Move: move, Moves: p.ValidMoves(), Result: result, Next: e}
+				g.stats.add(depth, rec, t.Steal)
+				if g.out != nil {
+					if err := g.out.Write(rec); err != nil {
+						return err
+					}
+				}
+				count = count + 1
+				fmt.Fprintf(os.Stderr, "\r%d", count)
+			}
+			// generate the todo list for the result
+			if result == game.EndOfTurn {
+				e = e.ChangePlayer()
+			}
+			g.seed(set, e)
+		}
+		// create new todo slice
+		todo = toGenerate(set)
+	}
+	return nil
+}
+
+// seed adds the moves of a position still to process
+func (g *generator) seed(set map[string]bool, p *game.Position) {
+	if g.minStones > 0 && stonesOnBoard(p) < g.minStones {
+		return
+	}
+	for _, move := range p.ValidMoves() {
+		key := createKey(p, move)
+		if !set[key] {
+			set[key] = false
+		}
+	}
+}
+
+// record reports if transitions from the position are to be recorded
+func (g *generator) record(p *game.Position) bool {
+	return g.maxStones == 0 || stonesOnBoard(p) <= g.maxStones
+}
+
+// stonesOnBoard counts the stones in holes, excluding homes
+func stonesOnBoard(p *game.Position) (n int) {
+	for _, row := range p.Row {
+		for _, v := range row.Items[1:] {
+			n += v
+		}
+	}
+	return
+}
+
+// parseStart validates a start position, defining the game
+// dimensions from the number of values and stones, which must
+// share equally between the holes
+func parseStart(csv string) (*game.Position, error) {
+	vals := strings.Split(csv, ",")
+	if len(vals) < 4 || len(vals)%2 != 0 {
+		return nil, fmt.Errorf("start position %q must have an even number of at least 4 values", csv)
+	}
+	width := len(vals)/2 - 1
+	total := 0
+	for _, v := range vals {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("start position %q has invalid value %q", csv, v)
+		}
+		total += n
+	}
+	if total == 0 || total%(2*width) != 0 {
+		return nil, fmt.Errorf("start position %q has %d stones, not a multiple of the %d holes", csv, total, 2*width)
+	}
+	game.DefineGame(width, total/(2*width))
+	return game.CreatePositionCsv(csv), nil
+}
+
+func createKey(p *game.Position, move int) string {
+	return fmt.Sprintf("%s;%d", p.AsCsv(), move)
+}
+
+func toGenerate(set map[string]bool) (todo []string) {
+	todo = make([]string, 0)
+	for k, v := range set {
+		if v == false {
+			todo = append(todo, k)
+		}
+	}
+	// sorted so output is repeatable
+	sort.Strings(todo)
+	return
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+	"github.com/stretchr/testify/assert"
+)
+
+// records collects the records written
+type records []*posfile.Record
+
+func (r *records) Write(rec *posfile.Record) error {
+	*r = append(*r, rec)
+	return nil
+}
+
+func (r *records) Close() error {
+	return nil
+}
+
+// generate runs a generator from the start of a 3 hole, 2 stone game
+func generate(t *testing.T, g *generator) records {
+	game.DefineGame(3, 2)
+	var out records
+	g.out, g.stats = &out, newStats()
+	assert.Nil(t, g.run(game.StartPosition()))
+	return out
+}
+
+func TestGenerateLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	all := generate(t, &generator{})
+
+	// the first ply is every move of the start
+	g := &generator{maxDepth: 1}
+	out := generate(t, g)
+	assert.Len(out, 3)
+	assert.Equal([]int{1}, g.stats.ByDepth)
+	for _, rec := range out {
+		assert.Equal(game.StartPosition().AsCsv(), rec.Position.AsCsv())
+	}
+
+	// the positions stop at the limit, their transitions all recorded
+	g = &generator{maxPositions: 5}
+	out = generate(t, g)
+	assert.Equal(5, g.stats.Positions)
+	assert.True(len(out) < len(all))
+
+	// pruning leaves no position with fewer stones
+	g = &generator{minStones: 6}
+	out = generate(t, g)
+	assert.NotEmpty(out)
+	assert.True(len(out) < len(all))
+	for _, rec := range out {
+		assert.True(stonesOnBoard(rec.Position) >= 6, rec.Position.AsCsv())
+	}
+
+	// positions above the maximum are walked but not recorded
+	g = &generator{maxStones: 4}
+	out = generate(t, g)
+	assert.NotEmpty(out)
+	for _, rec := range out {
+		assert.True(stonesOnBoard(rec.Position) <= 4, rec.Position.AsCsv())
+	}
+}
+
+func TestParseStart(t *testing.T) {
+	assert := assert.New(t)
+
+	pos, err := parseStart("3,1,0,2,1,0,1,4")
+	assert.Nil(err)
+	assert.Equal(3, game.WIDTH())
+	assert.Equal(2, game.STONE())
+	valid, _ := pos.IsValid()
+	assert.True(valid)
+
+	_, err = parseStart("3,1,0,2,1,0,1,5")
+	assert.EqualError(err, `start position "3,1,0,2,1,0,1,5" has 13 stones, not a multiple of the 6 holes`)
+	_, err = parseStart("0,0,0,0")
+	assert.EqualError(err, `start position "0,0,0,0" has 0 stones, not a multiple of the 2 holes`)
+	_, err = parseStart("1,2,3")
+	assert.EqualError(err, `start position "1,2,3" must have an even number of at least 4 values`)
+	_, err = parseStart("1,2,x,4")
+	assert.EqualError(err, `start position "1,2,x,4" has invalid value "x"`)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+)
+
+var queryFilename string
+var queryIndex string
+var queryReindex bool
+var queryPosition string
+var queryLeadsTo string
+var querySteals bool
+var queryLimit int
+
+// queryCmd answers questions about a generated position file
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Query a generated position file",
+	Long: `Query a generated position file
+using an index built from the file on first use.
+For example:
+
+mgenerate query --filename <filename> --position <csv>
+mgenerate query --filename <filename> --leads-to <csv>
+mgenerate query --filename <filename> --steals`,
+	Run: func(cmd *cobra.Command, args []string) {
+		idx, err := openIndex(
+			viper.GetString("query.filename"),
+			viper.GetString("query.index"),
+			viper.GetBool("query.reindex"),
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+		defer idx.Close()
+
+		switch {
+		case viper.GetString("query.position") != "":
+			p := game.CreatePositionCsv(viper.GetString("query.position"))
+			entries, err := idx.ByPosition(p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			// move, result, next and successor
+			for _, e := range entries {
+				fmt.Printf("%d %s %s %s%s\n", e.Move, e.Result, e.Next, e.Successor, annotate(e))
+			}
+		case viper.GetString("query.leadsto") != "":
+			p := game.CreatePositionCsv(viper.GetString("query.leadsto"))
+			entries, err := idx.BySuccessor(p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			// position, move and result
+			for _, e := range entries {
+				fmt.Printf("%s %d %s%s\n", e.Position, e.Move, e.Result, annotate(e))
+			}
+		case viper.GetBool("query.steals"):
+			entries, err := idx.Steals(viper.GetInt("query.limit"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			// position, move and result
+			for _, e := range entries {
+				fmt.Printf("%s %d %s%s\n", e.Position, e.Move, e.Result, annotate(e))
+			}
+		default:
+			h := idx.Header()
+			fmt.Printf("game %dx%d\n", h.Width, h.Stones)
+		}
+	},
+}
+
+// annotate adds the optional details of an entry
+func annotate(e *posfile.Entry) (s string) {
+	if e.Steal {
+		s += " steal"
+	}
+	if e.Count > 0 {
+		s += fmt.Sprintf(" count %d", e.Count)
+	}
+	return
+}
+
+// openIndex opens the index of a position file, building it if
+// needed or if the file has changed since it was indexed
+func openIndex(filename string, dir string, reindex bool) (*posfile.Index, error) {
+	if filename == "" && dir == "" {
+		return nil, fmt.Errorf("a filename or index is required")
+	}
+	if dir == "" {
+		dir = filename + ".idx"
+	}
+	if reindex {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(dir); err == nil {
+		idx, err := posfile.OpenIndex(dir)
+		if err != nil || filename == "" {
+			return idx, err
+		}
+		ok, err := idx.Matches(filename)
+		if err != nil {
+			idx.Close()
+			return nil, err
+		}
+		if ok {
+			return idx, nil
+		}
+		idx.Close()
+		fmt.Fprintf(os.Stderr, "%s has changed since it was indexed\n", filename)
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r, err := posfile.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "indexing %s into %s\n", filename, dir)
+	idx, err := posfile.BuildIndex(r, dir)
+	if err == nil {
+		if err = idx.SetSource(filename); err != nil {
+			idx.Close()
+		}
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return idx, nil
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVarP(&queryFilename, "filename", "f", "", "position filename to query")
+	queryCmd.Flags().StringVar(&queryIndex, "index", "", "index directory (default is <filename>.idx)")
+	queryCmd.Flags().BoolVar(&queryReindex, "reindex", false, "rebuild the index")
+	queryCmd.Flags().StringVarP(&queryPosition, "position", "p", "", "list the moves from a position csv")
+	queryCmd.Flags().StringVar(&queryLeadsTo, "leads-to", "", "list the positions and moves leading to a position csv")
+	queryCmd.Flags().BoolVar(&querySteals, "steals", false, "list the positions and moves with a steal")
+	queryCmd.Flags().IntVar(&queryLimit, "limit", 0, "maximum steals to list, 0 is unlimited")
+
+	viper.BindPFlag("query.filename", queryCmd.Flags().Lookup("filename"))
+	viper.BindPFlag("query.index", queryCmd.Flags().Lookup("index"))
+	viper.BindPFlag("query.reindex", queryCmd.Flags().Lookup("reindex"))
+	viper.BindPFlag("query.position", queryCmd.Flags().Lookup("position"))
+	viper.BindPFlag("query.leadsto", queryCmd.Flags().Lookup("leads-to"))
+	viper.BindPFlag("query.steals", queryCmd.Flags().Lookup("steals"))
+	viper.BindPFlag("query.limit", queryCmd.Flags().Lookup("limit"))
+}
@@ -2,13 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/EFX-PXT1/mancala-go/cmd/internal/weights"
 	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
@@ -18,6 +20,17 @@ var cfgFile string
 var width int
 var stones int
 var filename string
+var format string
+var compress bool
+var maxDepth int
+var maxPositions int
+var minStones int
+var maxStones int
+var from string
+var sample int
+var seed int64
+var player string
+var statsFormat string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -27,7 +40,19 @@ var rootCmd = &cobra.Command{
 creates all possible position for a mancala game
 For example:
 
-mgenerate --width <width> --stones <start stones> --file <filename>`,
+mgenerate --width <width> --stones <start stones> --file <filename> --format <text|binary>
+
+or to view the first few plies
+
+mgenerate --file <filename>.dot --format dot --max-depth 3
+
+or to explore an endgame slice
+
+mgenerate --from <csv> --max-stones-on-board 12 --file <filename>
+
+or to sample random games of a standard board
+
+mgenerate --width 6 --stones 4 --sample 1000 --seed 1 --file <filename>`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
@@ -37,59 +62,86 @@ mgenerate --width <width> --stones <start stones> --file <filename>`,
 		)
 
 		filename := viper.GetString("generator.filename")
+		statsFormat := viper.GetString("generator.stats")
+		if statsFormat != "text" && statsFormat != "json" && statsFormat != "none" {
+			fmt.Fprintf(os.Stderr, "error: unknown stats format %q, must be one of: text, json, none\n", statsFormat)
+			return
+		}
 
 		p := game.StartPosition()
-
-		// initial seed position and moves
-		set := make(map[string]bool) // empty set of processed or to process
-		moves := p.ValidMoves()
-		for _, m := range moves {
-			set[createKey(p, m)] = false
+		if from := viper.GetString("generator.from"); from != "" {
+			var err error
+			if p, err = parseStart(from); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
 		}
 
-		var file *os.File
+		var out posfile.Writer
 		if filename != "" {
-			var err error
-			file, err = os.Create(filename)
+			fileFormat, err := posfile.ParseFormat(viper.GetString("generator.format"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			if viper.GetBool("generator.compress") && fileFormat != posfile.Binary {
+				fmt.Fprintf(os.Stderr, "error: --compress needs the binary format, not %s\n", fileFormat)
+				return
+			}
+			file, err := os.Create(filename)
 			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				return
 			}
 			defer file.Close()
+			header := posfile.NewHeader(viper.GetBool("generator.compress"))
+			if viper.GetInt("generator.sample") > 0 {
+				header = posfile.NewCountsHeader(header.Compressed)
+			}
+			out, err = posfile.NewWriter(file, fileFormat, header)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			defer out.Close()
 		}
 
-		count := 0
-
-		// create a todo slice
-		todo := toGenerate(set)
-
-		for len(todo) > 0 {
-			for _, k := range todo {
-				// evaluate this single todo move
-				s := strings.Split(k, ";")
-				p = game.CreatePositionCsv(s[0])
-				move, _ := strconv.Atoi(s[1])
-				moves := p.ValidMoves()
-				e, _, result, _ := p.Move(move)
-				if file != nil {
-					writeLine(file, p, moves, move, result, e)
-				}
-				set[k] = true
-				count = count + 1
-				fmt.Printf("\r%d", count)
-				// generate the todo list for the result
-				if result == game.EndOfTurn {
-					e = e.ChangePlayer()
-				}
-				moves = e.ValidMoves()
-				for _, move = range moves {
-					key := createKey(e, move)
-					if !set[key] {
-						set[key] = false
-					}
-				}
+		st := newStats()
+		var err error
+		if games := viper.GetInt("generator.sample"); games > 0 {
+			seed := viper.GetInt64("generator.seed")
+			if seed == 0 {
+				seed = time.Now().UTC().UnixNano()
+				fmt.Fprintf(os.Stderr, "seed %d\n", seed)
+			}
+			rand.Seed(seed)
+			s := &sampler{
+				out:   out,
+				stats: st,
+				games: games,
+				conf:  samplerConf(viper.GetString("generator.player")),
 			}
-			// create new todo slice
-			todo = toGenerate(set)
+			err = s.run(p)
+		} else {
+			g := &generator{
+				out:          out,
+				stats:        st,
+				maxDepth:     viper.GetInt("generator.maxdepth"),
+				maxPositions: viper.GetInt("generator.maxpositions"),
+				minStones:    viper.GetInt("generator.minstones"),
+				maxStones:    viper.GetInt("generator.maxstones"),
+			}
+			err = g.run(p)
+		}
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		st.finish()
+		if statsFormat != "none" {
+			st.write(os.Stdout, statsFormat)
 		}
 	},
 }
@@ -110,16 +162,39 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.mancala.yaml)")
+	weights.AddFlag(rootCmd)
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().IntVarP(&width, "width", "w", 3, "width of board")
 	rootCmd.Flags().IntVarP(&stones, "stones", "s", 2, "intial number of stones")
 	rootCmd.Flags().StringVarP(&filename, "filename", "f", "", "position filename to generate")
+	rootCmd.Flags().StringVar(&format, "format", "text", "position file format <text|binary|dot|graphml>")
+	rootCmd.Flags().BoolVarP(&compress, "compress", "z", false, "gzip compress binary position file, only for the binary format")
+	rootCmd.Flags().IntVarP(&maxDepth, "max-depth", "d", 0, "maximum plies from the start, 0 is unlimited")
+	rootCmd.Flags().IntVar(&maxPositions, "max-positions", 0, "maximum positions to record, 0 is unlimited")
+	rootCmd.Flags().IntVar(&minStones, "min-stones-on-board", 0, "prune positions with fewer stones in holes")
+	rootCmd.Flags().IntVar(&maxStones, "max-stones-on-board", 0, "only record positions with at most these stones in holes, 0 is unlimited")
+	rootCmd.Flags().StringVar(&from, "from", "", "start position csv, overriding width and stones")
+	rootCmd.Flags().IntVar(&sample, "sample", 0, "play this many games instead of generating all positions")
+	rootCmd.Flags().Int64Var(&seed, "seed", 0, "random seed for sampling, 0 is time based")
+	rootCmd.Flags().StringVarP(&player, "type", "t", "random", "player type for sampling")
+	rootCmd.Flags().StringVar(&statsFormat, "stats", "text", "summary report format <text|json|none>")
 
 	viper.BindPFlag("game.width", rootCmd.Flags().Lookup("width"))
 	viper.BindPFlag("game.stones", rootCmd.Flags().Lookup("stones"))
 	viper.BindPFlag("generator.filename", rootCmd.Flags().Lookup("filename"))
+	viper.BindPFlag("generator.format", rootCmd.Flags().Lookup("format"))
+	viper.BindPFlag("generator.compress", rootCmd.Flags().Lookup("compress"))
+	viper.BindPFlag("generator.maxdepth", rootCmd.Flags().Lookup("max-depth"))
+	viper.BindPFlag("generator.maxpositions", rootCmd.Flags().Lookup("max-positions"))
+	viper.BindPFlag("generator.minstones", rootCmd.Flags().Lookup("min-stones-on-board"))
+	viper.BindPFlag("generator.maxstones", rootCmd.Flags().Lookup("max-stones-on-board"))
+	viper.BindPFlag("generator.from", rootCmd.Flags().Lookup("from"))
+	viper.BindPFlag("generator.sample", rootCmd.Flags().Lookup("sample"))
+	viper.BindPFlag("generator.seed", rootCmd.Flags().Lookup("seed"))
+	viper.BindPFlag("generator.player", rootCmd.Flags().Lookup("type"))
+	viper.BindPFlag("generator.stats", rootCmd.Flags().Lookup("stats"))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -146,33 +221,17 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		//	fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
-}
 
-func createKey(p *game.Position, move int) string {
-	return fmt.Sprintf("%s;%d", p.AsCsv(), move)
+	weights.Register()
 }
 
-func writeLine(f *os.File, p *game.Position, moves []int, move int, result game.MoveResult, e *game.Position) {
-	var m []string
-	for _, i := range moves {
-		m = append(m, strconv.Itoa(i))
-	}
-	s := fmt.Sprintf("%s;%s;%d;%s\n",
-		createKey(p, move),
-		strings.Join(m, ","),
-		result,
-		e.AsCsv(),
-	)
-	//	fmt.Printf(s)
-	f.WriteString(s)
-}
-
-func toGenerate(set map[string]bool) (todo []string) {
-	todo = make([]string, 0)
-	for k, v := range set {
-		if v == false {
-			todo = append(todo, k)
-		}
+// samplerConf creates the configuration for the sampling player type
+// from its block in the config file, quietened where possible
+func samplerConf(playerType string) map[string]string {
+	conf := viper.GetStringMapString("players." + playerType)
+	conf["type"] = playerType
+	if t, err := game.LookupPlayerType(playerType); err == nil && t.HasParam("quiet") {
+		conf["quiet"] = "true"
 	}
-	return
+	return conf
 }
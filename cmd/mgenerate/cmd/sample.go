@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+	// register the external and search player types
+	_ "github.com/EFX-PXT1/mancala-go/pkg/protocol"
+	_ "github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+// visit is a sampled transition with its frequency
+type visit struct {
+	rec   *posfile.Record
+	depth int
+	steal bool
+}
+
+// sampler plays whole games recording each visited transition,
+// for boards too large to generate exhaustively
+type sampler struct {
+	out   posfile.Writer
+	stats *stats
+
+	// games is the number of games to play
+	games int
+	// conf creates the player for both sides
+	conf map[string]string
+
+	visits map[string]*visit
+}
+
+// run plays the games from the start position then writes the
+// visited transitions in ply order with their frequency counts
+func (s *sampler) run(start *game.Position) error {
+	var agents [2]game.Agent
+	for i := range agents {
+		agent, err := game.CreateAgent(s.conf)
+		if err != nil {
+			return err
+		}
+		if c, ok := agent.(io.Closer); ok {
+			defer c.Close()
+		}
+		agents[i] = agent
+	}
+
+	s.visits = make(map[string]*visit)
+	for i := 0; i < s.games; i++ {
+		if err := s.play(start, agents); err != nil {
+			return fmt.Errorf("game %d: %v", i+1, err)
+		}
+		fmt.Fprintf(os.Stderr, "\r%d", i+1)
+	}
+
+	keys := make([]string, 0, len(s.visits))
+	for k := range s.visits {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := s.visits[keys[i]], s.visits[keys[j]]
+		if a.depth != b.depth {
+			return a.depth < b.depth
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		v := s.visits[k]
+		s.stats.add(v.depth, v.rec, v.steal)
+		if s.out != nil {
+			if err := s.out.Write(v.rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// play a single game to the end
+func (s *sampler) play(start *game.Position, agents [2]game.Agent) error {
+	depth := 0
+	_, err := game.PlayGame(context.Background(), agents, start,
+		func(side int, pos *game.Position, move int, t *game.Transition) {
+			key := createKey(pos, move)
+			v, ok := s.visits[key]
+			if !ok {
+				v = &visit{
+					rec: &posfile.Record{
+						Position: pos,
+						Move:     move,
+						Moves:    pos.ValidMoves(),
+						Result:   t.Result,
+						Next:     t.Next,
+					},
+					depth: depth,
+					steal: t.Steal,
+				}
+				s.visits[key] = v
+			}
+			v.rec.Count++
+			if depth < v.depth {
+				v.depth = depth
+			}
+			depth++
+		})
+	return err
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+)
+
+// stats summarises a generation run
+type stats struct {
+	Width       int            `json:"width"`
+	Stones      int            `json:"stones"`
+	Transitions int            `json:"transitions"`
+	Positions   int            `json:"positions"`
+	ByDepth     []int          `json:"positionsByDepth"`
+	Branching   map[int]int    `json:"branchingFactor"`
+	Results     map[string]int `json:"results"`
+	Steals      int            `json:"steals"`
+	Scores      map[int]int    `json:"terminalScores"`
+	Elapsed     float64        `json:"elapsedSeconds"`
+	Throughput  float64        `json:"transitionsPerSecond"`
+	PeakMemory  uint64         `json:"peakMemoryBytes"`
+
+	start time.Time
+	// seen are the positions of the run, atDepth those of each ply
+	seen    map[string]bool
+	atDepth map[depthKey]bool
+}
+
+// depthKey is a position reached at a ply depth
+type depthKey struct {
+	depth int
+	pos   string
+}
+
+func newStats() *stats {
+	return &stats{
+		Width:     game.WIDTH(),
+		Stones:    game.STONE(),
+		Branching: make(map[int]int),
+		Results:   make(map[string]int),
+		Scores:    make(map[int]int),
+		start:     time.Now(),
+		seen:      make(map[string]bool),
+		atDepth:   make(map[depthKey]bool),
+	}
+}
+
+// add counts a single transition at a ply depth. Positions are
+// distinct over the run, those by depth distinct within each ply,
+// so a position reached at several plies counts at each.
+func (s *stats) add(depth int, rec *posfile.Record, steal bool) {
+	for len(s.ByDepth) <= depth {
+		s.ByDepth = append(s.ByDepth, 0)
+	}
+	key := rec.Position.AsCsv()
+	if !s.seen[key] {
+		s.seen[key] = true
+		s.Positions++
+		s.Branching[len(rec.Moves)]++
+	}
+	if dk := (depthKey{depth, key}); !s.atDepth[dk] {
+		s.atDepth[dk] = true
+		s.ByDepth[depth]++
+	}
+	s.Transitions++
+	s.Results[rec.Result.String()]++
+	if steal {
+		s.Steals++
+	}
+	if rec.Result == game.EndOfGame {
+		s.Scores[rec.Next.Row[0].Items[0]-rec.Next.Row[1].Items[0]]++
+	}
+	if s.Transitions%4096 == 0 {
+		s.sample()
+	}
+}
+
+// counted reports if the position has already been counted
+func (s *stats) counted(p *game.Position) bool {
+	return s.seen[p.AsCsv()]
+}
+
+// sample records the peak memory in use
+func (s *stats) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc > s.PeakMemory {
+		s.PeakMemory = m.HeapAlloc
+	}
+}
+
+// finish computes the timings
+func (s *stats) finish() {
+	s.sample()
+	s.Elapsed = time.Since(s.start).Seconds()
+	if s.Elapsed > 0 {
+		s.Throughput = float64(s.Transitions) / s.Elapsed
+	}
+}
+
+// write outputs the report as json or otherwise text
+func (s *stats) write(w io.Writer, format string) {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(s)
+		return
+	}
+	s.writeText(w)
+}
+
+func (s *stats) writeText(w io.Writer) {
+	fmt.Fprintf(w, "game          %dx%d\n", s.Width, s.Stones)
+	fmt.Fprintf(w, "transitions   %d\n", s.Transitions)
+	fmt.Fprintf(w, "positions     %d\n", s.Positions)
+	fmt.Fprintf(w, "steals        %d\n", s.Steals)
+	for _, r := range []game.MoveResult{game.EndOfTurn, game.RepeatTurn, game.EndOfGame} {
+		fmt.Fprintf(w, "%-13s %d\n", r, s.Results[r.String()])
+	}
+	fmt.Fprintf(w, "elapsed       %.3fs\n", s.Elapsed)
+	fmt.Fprintf(w, "throughput    %.0f transitions/s\n", s.Throughput)
+	fmt.Fprintf(w, "peak memory   %.1f MiB\n", float64(s.PeakMemory)/(1<<20))
+	fmt.Fprintf(w, "positions by depth\n")
+	for d, n := range s.ByDepth {
+		fmt.Fprintf(w, "  %4d %d\n", d, n)
+	}
+	fmt.Fprintf(w, "branching factor\n")
+	writeHistogram(w, s.Branching)
+	fmt.Fprintf(w, "terminal score\n")
+	writeHistogram(w, s.Scores)
+}
+
+// writeHistogram writes counts in key order
+func writeHistogram(w io.Writer, h map[int]int) {
+	keys := make([]int, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %4d %d\n", k, h[k])
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsDistinct(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	p := game.StartPosition()
+	other := game.CreatePosition(0, 0, 2, 2, 0, 2, 2, 4)
+	rec := func(pos *game.Position) *posfile.Record {
+		next, _, result, _ := pos.Move(pos.ValidMoves()[0])
+		return &posfile.Record{Position: pos, Move: pos.ValidMoves()[0], Moves: pos.ValidMoves(), Result: result, Next: next}
+	}
+
+	// the same position at two depths, interleaved as when sampling
+	s := newStats()
+	s.add(0, rec(p), false)
+	s.add(1, rec(other), false)
+	s.add(2, rec(p), false)
+	s.add(0, rec(p), false)
+	s.add(2, rec(p), false)
+
+	assert.Equal(5, s.Transitions)
+	assert.Equal(2, s.Positions)
+	assert.Equal([]int{1, 1, 1}, s.ByDepth)
+	assert.Equal(map[int]int{3: 1, 2: 1}, s.Branching)
+	assert.True(s.counted(p))
+}
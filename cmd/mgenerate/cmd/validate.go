@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/posfile"
+)
+
+var validateFilename string
+var validateMax int
+
+// validateCmd replays a position file through the engine
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a position file against the engine",
+	Long: `Validate a position file against the engine
+by replaying every position and move, reporting where the
+recorded valid moves, result or next position disagree.
+Files written by other implementations can be checked
+against this reference. For example:
+
+mgenerate validate --filename <filename> --max-errors 10`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := os.Open(viper.GetString("validate.filename"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		r, err := posfile.NewReader(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		max := viper.GetInt("validate.maxerrors")
+		reported := 0
+		records, divergent, err := posfile.Validate(r, func(d posfile.Divergence) {
+			if max == 0 || reported < max {
+				fmt.Println(d)
+			}
+			reported++
+		})
+		h := r.Header()
+		fmt.Printf("game %dx%d records %d divergent %d\n", h.Width, h.Stones, records, divergent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: record %d: %v\n", records+1, err)
+			os.Exit(1)
+		}
+		if divergent > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&validateFilename, "filename", "f", "", "position filename to validate")
+	validateCmd.Flags().IntVar(&validateMax, "max-errors", 20, "maximum divergences to report, 0 is unlimited")
+
+	viper.BindPFlag("validate.filename", validateCmd.Flags().Lookup("filename"))
+	viper.BindPFlag("validate.maxerrors", validateCmd.Flags().Lookup("max-errors"))
+}
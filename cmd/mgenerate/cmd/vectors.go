@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+var vectorsFilename string
+var vectorsWidths []int
+var vectorsStones []int
+var vectorsGames int
+var vectorsSeed int64
+
+// vectorsCmd writes the conformance test vectors
+var vectorsCmd = &cobra.Command{
+	Use:   "vectors",
+	Short: "Write conformance test vectors as JSON",
+	Long: `Write conformance test vectors as JSON
+for checking ports of the rules to other languages.
+Hand picked edge cases are followed by every move of random games
+for each width and stones, the same seed always giving the same suite.
+For example:
+
+mgenerate vectors --filename vectors.json --widths 3,4,6 --stones 1,2,4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		suite, err := game.BuildSuite(
+			viper.GetIntSlice("vectors.widths"),
+			viper.GetIntSlice("vectors.stones"),
+			viper.GetInt("vectors.games"),
+			viper.GetInt64("vectors.seed"),
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		out := os.Stdout
+		if filename := viper.GetString("vectors.filename"); filename != "" {
+			if out, err = os.Create(filename); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return
+			}
+			defer out.Close()
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", " ")
+		if err = enc.Encode(suite); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vectorsCmd)
+
+	vectorsCmd.Flags().StringVarP(&vectorsFilename, "filename", "f", "", "vectors filename (default is stdout)")
+	vectorsCmd.Flags().IntSliceVar(&vectorsWidths, "widths", []int{1, 2, 3, 4, 5, 6}, "widths of board to sample")
+	vectorsCmd.Flags().IntSliceVar(&vectorsStones, "stones", []int{1, 2, 3, 4, 5, 6}, "initial stones to sample")
+	vectorsCmd.Flags().IntVar(&vectorsGames, "games", 2, "random games per width and stones")
+	vectorsCmd.Flags().Int64Var(&vectorsSeed, "seed", 1, "random seed")
+
+	viper.BindPFlag("vectors.filename", vectorsCmd.Flags().Lookup("filename"))
+	viper.BindPFlag("vectors.widths", vectorsCmd.Flags().Lookup("widths"))
+	viper.BindPFlag("vectors.stones", vectorsCmd.Flags().Lookup("stones"))
+	viper.BindPFlag("vectors.games", vectorsCmd.Flags().Lookup("games"))
+	viper.BindPFlag("vectors.seed", vectorsCmd.Flags().Lookup("seed"))
+}
@@ -0,0 +1,406 @@
+// Package book builds and plays opening books
+package book
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	bh "github.com/timshannon/badgerhold/v2"
+)
+
+// headerKey stores the header within a book
+const headerKey = "header"
+
+// Header describes the game and how a book was built
+type Header struct {
+	Width, Stones int
+	// Source is how the moves were chosen, analysis or games
+	Source string
+}
+
+// Move is a book move from a position
+type Move struct {
+	Hole int
+	// Weight is the chance of playing the move against the others, 0 for never
+	Weight int
+	// Score is from the side to move
+	Score float64
+}
+
+// Entry is the book moves of a position, keyed by its Hash
+type Entry struct {
+	Key uint64 `badgerhold:"key"`
+	// Position is the AsCsv of the position, from the side to move
+	Position string
+	Moves    []Move
+}
+
+// Book is an opening tree, held in memory
+type Book struct {
+	Header
+	entries map[uint64]*Entry
+}
+
+// New creates an empty book of a game
+func New(width int, stones int, source string) *Book {
+	return &Book{
+		Header:  Header{Width: width, Stones: stones, Source: source},
+		entries: make(map[uint64]*Entry),
+	}
+}
+
+// Start is the start position of the game of the book
+func (b *Book) Start() *game.Position {
+	p := &game.Position{}
+	for i := range p.Row {
+		p.Row[i].Items = make([]int, b.Width+1)
+		for h := 1; h <= b.Width; h++ {
+			p.Row[i].Items[h] = b.Stones
+		}
+	}
+	return p
+}
+
+// Len is the number of positions in the book
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// Entries lists the positions of the book, in key order
+func (b *Book) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// Lookup finds the moves of a position, best first,
+// nil if it is not in the book or is of another game
+func (b *Book) Lookup(pos *game.Position) []Move {
+	if !b.fits(pos) {
+		return nil
+	}
+	if e, ok := b.entries[pos.Hash()]; ok && e.Position == pos.AsCsv() {
+		return e.Moves
+	}
+	return nil
+}
+
+// fits checks a position is of the width and stones of the book
+func (b *Book) fits(pos *game.Position) bool {
+	if len(pos.Row[0].Items) != b.Width+1 || len(pos.Row[1].Items) != b.Width+1 {
+		return false
+	}
+	total := 0
+	for _, r := range pos.Row {
+		for _, v := range r.Items {
+			total += v
+		}
+	}
+	return total == 2*b.Width*b.Stones
+}
+
+// Set replaces the moves of a position, sorting them best first.
+// It fails rather than replace another position of the same key.
+func (b *Book) Set(pos *game.Position, moves []Move) error {
+	if err := b.collision(pos); err != nil {
+		return err
+	}
+	moves = append([]Move(nil), moves...)
+	sortMoves(moves)
+	b.entries[pos.Hash()] = &Entry{Key: pos.Hash(), Position: pos.AsCsv(), Moves: moves}
+	return nil
+}
+
+// collision checks no other position of the book has the key of pos
+func (b *Book) collision(pos *game.Position) error {
+	if e, ok := b.entries[pos.Hash()]; ok && e.Position != pos.AsCsv() {
+		return fmt.Errorf("position %s has the key of %s", pos.AsCsv(), e.Position)
+	}
+	return nil
+}
+
+// sortMoves orders moves by weight and then score, best first
+func sortMoves(moves []Move) {
+	sort.SliceStable(moves, func(i, j int) bool {
+		if moves[i].Weight != moves[j].Weight {
+			return moves[i].Weight > moves[j].Weight
+		}
+		return moves[i].Score > moves[j].Score
+	})
+}
+
+// Choose picks a book move at random by weight, the rnd source
+// being the global one when nil. ok is false out of the book.
+func (b *Book) Choose(pos *game.Position, rnd *rand.Rand) (hole int, ok bool) {
+	moves := b.Lookup(pos)
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+	if total == 0 {
+		return 0, false
+	}
+	var r int
+	if rnd != nil {
+		r = rnd.Intn(total)
+	} else {
+		r = rand.Intn(total)
+	}
+	for _, m := range moves {
+		if r < m.Weight {
+			return m.Hole, true
+		}
+		r -= m.Weight
+	}
+	return 0, false
+}
+
+// Scorer scores every move of a position from the side to move
+type Scorer func(ctx context.Context, pos *game.Position) ([]Move, error)
+
+// Analyse adds the positions reached in plies moves from the start,
+// following every move scoring within margin of the best. Moves are
+// weighted from 100 for the best down to 1 at the margin, those
+// outside it being kept with a weight of 0. progress, if not nil,
+// is called with the positions analysed.
+func (b *Book) Analyse(ctx context.Context, plies int, margin float64, score Scorer, progress func(n int)) error {
+	type node struct {
+		pos *game.Position
+		ply int
+	}
+	seen := make(map[string]bool)
+	queue := []node{{b.Start(), 0}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.ply >= plies || n.pos.IsGameEnd() || seen[n.pos.AsCsv()] {
+			continue
+		}
+		seen[n.pos.AsCsv()] = true
+
+		moves, err := score(ctx, n.pos)
+		if err != nil {
+			return fmt.Errorf("position %s: %v", n.pos.AsCsv(), err)
+		}
+		best := math.Inf(-1)
+		for _, m := range moves {
+			best = math.Max(best, m.Score)
+		}
+		for i, m := range moves {
+			moves[i].Weight = weight(best-m.Score, margin)
+			if moves[i].Weight == 0 {
+				continue
+			}
+			t, err := n.pos.Play(m.Hole)
+			if err != nil {
+				return fmt.Errorf("position %s: move %d: %v", n.pos.AsCsv(), m.Hole, err)
+			}
+			next := t.Next
+			if t.Result == game.EndOfTurn {
+				next = next.ChangePlayer()
+			}
+			queue = append(queue, node{next, n.ply + 1})
+		}
+		if err := b.Set(n.pos, moves); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(len(b.entries))
+		}
+	}
+	return nil
+}
+
+// weight is 100 for no loss down to 1 at the margin, and 0 beyond it
+func weight(loss float64, margin float64) int {
+	switch {
+	case loss > margin:
+		return 0
+	case margin == 0:
+		return 100
+	}
+	return 1 + int(math.Round(99*(1-loss/margin)))
+}
+
+// AddGame adds the first plies moves of a game from the start, weighting
+// each move by the times played and scoring it by the mean final store
+// margin from the side moving, score being that of the first player.
+// Nothing is added from a game with an invalid move, or with a
+// position sharing its key with another in the book.
+func (b *Book) AddGame(moves []int, plies int, score int) error {
+	type ply struct {
+		pos   *game.Position
+		hole  int
+		score float64
+	}
+	var added []ply
+	pos, side := b.Start(), 0
+	for i, hole := range moves {
+		if i >= plies {
+			break
+		}
+		t, err := pos.Play(hole)
+		if err != nil {
+			return fmt.Errorf("move %d of %d from %s: %v", i+1, hole, pos.AsCsv(), err)
+		}
+		if err := b.collision(pos); err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+		x := float64(score)
+		if side == 1 {
+			x = -x
+		}
+		added = append(added, ply{pos, hole, x})
+		if t.Result == game.EndOfGame {
+			break
+		}
+		pos = t.Next
+		if t.Result == game.EndOfTurn {
+			pos, side = pos.ChangePlayer(), 1-side
+		}
+	}
+	for _, p := range added {
+		if err := b.played(p.pos, p.hole, p.score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// played counts a move of a game, keeping the mean score
+func (b *Book) played(pos *game.Position, hole int, score float64) error {
+	var moves []Move
+	if e, ok := b.entries[pos.Hash()]; ok && e.Position == pos.AsCsv() {
+		moves = e.Moves
+	}
+	i := 0
+	for i < len(moves) && moves[i].Hole != hole {
+		i++
+	}
+	if i == len(moves) {
+		moves = append(moves, Move{Hole: hole})
+	}
+	moves[i].Weight++
+	moves[i].Score += (score - moves[i].Score) / float64(moves[i].Weight)
+	return b.Set(pos, moves)
+}
+
+// Prune drops the moves played fewer than min times, and then any
+// positions left without moves
+func (b *Book) Prune(min int) {
+	for key, e := range b.entries {
+		moves := e.Moves[:0]
+		for _, m := range e.Moves {
+			if m.Weight >= min {
+				moves = append(moves, m)
+			}
+		}
+		e.Moves = moves
+		if len(moves) == 0 {
+			delete(b.entries, key)
+		}
+	}
+}
+
+func openStore(dir string) (*bh.Store, error) {
+	options := bh.DefaultOptions
+	options.Dir = dir
+	options.ValueDir = dir
+	options.Logger = nil
+	return bh.Open(options)
+}
+
+// Save adds the book to any store in dir. The moves of a position
+// already there are merged, summing the weights of each move and
+// averaging its scores by weight, so a book built from more games
+// counts them all. It fails on a position sharing the key of another.
+func (b *Book) Save(dir string) error {
+	store, err := openStore(dir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	h := b.Header
+	var old Header
+	if err := store.Get(headerKey, &old); err == nil {
+		if old.Width != b.Width || old.Stones != b.Stones {
+			return fmt.Errorf("book %s is of width %d stones %d", dir, old.Width, old.Stones)
+		}
+		if old.Source != "" && old.Source != h.Source {
+			h.Source = old.Source + "; " + h.Source
+		}
+	}
+	// merge everything before writing anything
+	entries := b.Entries()
+	for i, e := range entries {
+		var saved Entry
+		if err := store.Get(e.Key, &saved); err == nil {
+			if saved.Position != e.Position {
+				return fmt.Errorf("book %s: position %s has the key of %s", dir, e.Position, saved.Position)
+			}
+			entries[i] = merge(&saved, e)
+		}
+	}
+	for _, e := range entries {
+		if err := store.Upsert(e.Key, e); err != nil {
+			return err
+		}
+	}
+	return store.Upsert(headerKey, h)
+}
+
+// merge adds the moves of e to those saved of the same position,
+// a move without weight on either side taking the score of e
+func merge(saved *Entry, e *Entry) *Entry {
+	moves := append([]Move(nil), saved.Moves...)
+	for _, m := range e.Moves {
+		i := 0
+		for i < len(moves) && moves[i].Hole != m.Hole {
+			i++
+		}
+		if i == len(moves) {
+			moves = append(moves, m)
+			continue
+		}
+		if total := moves[i].Weight + m.Weight; total > 0 {
+			moves[i].Score = (moves[i].Score*float64(moves[i].Weight) + m.Score*float64(m.Weight)) / float64(total)
+			moves[i].Weight = total
+		} else {
+			moves[i].Score = m.Score
+		}
+	}
+	sortMoves(moves)
+	return &Entry{Key: e.Key, Position: e.Position, Moves: moves}
+}
+
+// Open reads the book of a store in dir into memory
+func Open(dir string) (*Book, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("book %s: %v", dir, err)
+	}
+	store, err := openStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	b := &Book{entries: make(map[uint64]*Entry)}
+	if err := store.Get(headerKey, &b.Header); err != nil {
+		return nil, fmt.Errorf("book %s: %v", dir, err)
+	}
+	var entries []*Entry
+	if err := store.Find(&entries, nil); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		b.entries[e.Key] = e
+	}
+	return b, nil
+}
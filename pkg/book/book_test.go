@@ -0,0 +1,135 @@
+package book
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGames(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(3, 1, "games")
+	assert.Nil(b.AddGame([]int{1, 2, 1, 2}, 4, 2))
+	assert.Nil(b.AddGame([]int{1, 2}, 4, 0))
+	assert.Nil(b.AddGame([]int{2, 1}, 1, -1))
+	assert.EqualError(b.AddGame([]int{1, 9}, 4, 0), "move 2 of 9 from 1,0,1,1,0,1,1,1: hole not in range")
+
+	// moves are weighted by the times played with the mean score of the mover
+	start := b.Start()
+	assert.Equal([]Move{{Hole: 1, Weight: 2, Score: 1}, {Hole: 2, Weight: 1, Score: -1}}, b.Lookup(start))
+	t1, _ := start.Play(1)
+	assert.Equal(game.RepeatTurn, t1.Result)
+	assert.Equal([]Move{{Hole: 2, Weight: 2, Score: 1}}, b.Lookup(t1.Next))
+	t2, _ := t1.Next.Play(2)
+	assert.Equal([]Move{{Hole: 1, Weight: 1, Score: -2}}, b.Lookup(t2.Next.ChangePlayer()))
+	assert.Equal(4, b.Len())
+
+	// positions of another game are not in the book
+	game.DefineGame(3, 2)
+	assert.Nil(b.Lookup(game.StartPosition()))
+	game.DefineGame(4, 1)
+	assert.Nil(b.Lookup(game.StartPosition()))
+
+	b.Prune(2)
+	assert.Equal(2, b.Len())
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		hole, ok := b.Choose(start, rnd)
+		assert.True(ok)
+		assert.Equal(1, hole)
+	}
+	_, ok := b.Choose(t2.Next.ChangePlayer(), rnd)
+	assert.False(ok)
+}
+
+func TestAnalyse(t *testing.T) {
+	assert := assert.New(t)
+
+	// the lower holes score better
+	score := func(ctx context.Context, pos *game.Position) ([]Move, error) {
+		var moves []Move
+		for _, h := range pos.ValidMoves() {
+			moves = append(moves, Move{Hole: h, Score: float64(-h)})
+		}
+		return moves, nil
+	}
+	b := New(4, 3, "analysis")
+	positions := 0
+	assert.Nil(b.Analyse(context.Background(), 2, 1, score, func(n int) { positions = n }))
+	assert.Equal([]Move{{Hole: 1, Weight: 100, Score: -1}, {Hole: 2, Weight: 1, Score: -2},
+		{Hole: 3, Weight: 0, Score: -3}, {Hole: 4, Weight: 0, Score: -4}}, b.Lookup(b.Start()))
+	assert.Equal(3, b.Len())
+	assert.Equal(3, positions)
+
+	// the best move only without a margin
+	b = New(4, 3, "analysis")
+	assert.Nil(b.Analyse(context.Background(), 3, 0, score, nil))
+	assert.Equal(3, b.Len())
+
+	// chosen in proportion to the weights
+	rnd := rand.New(rand.NewSource(1))
+	counts := make(map[int]int)
+	for i := 0; i < 1010; i++ {
+		hole, ok := b.Choose(b.Start(), rnd)
+		assert.True(ok)
+		counts[hole]++
+	}
+	assert.Equal(map[int]int{1: 1010}, counts)
+}
+
+func TestSaveOpen(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "book-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	b := New(3, 1, "games")
+	assert.Nil(b.AddGame([]int{1, 2, 1, 2}, 4, 0))
+	assert.Nil(b.Save(dir))
+
+	opened, err := Open(dir)
+	assert.Nil(err)
+	assert.Equal(b.Header, opened.Header)
+	assert.Equal(b.Entries(), opened.Entries())
+
+	assert.EqualError(New(4, 3, "games").Save(dir), "book "+dir+" is of width 3 stones 1")
+	_, err = Open(dir + "-missing")
+	assert.NotNil(err)
+}
+
+func TestSaveMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "book-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	// a second build adds to the counts of the first
+	b := New(3, 1, "games 2")
+	assert.Nil(b.AddGame([]int{1, 2}, 4, 2))
+	assert.Nil(b.AddGame([]int{2, 1}, 4, -1))
+	assert.Nil(b.Save(dir))
+	b = New(3, 1, "games 1")
+	assert.Nil(b.AddGame([]int{1, 2}, 4, -4))
+	assert.Nil(b.Save(dir))
+
+	opened, err := Open(dir)
+	assert.Nil(err)
+	assert.Equal("games 2; games 1", opened.Source)
+	assert.Equal([]Move{{Hole: 1, Weight: 2, Score: -1}, {Hole: 2, Weight: 1, Score: -1}}, opened.Lookup(b.Start()))
+
+	// another position of the same key is not replaced
+	start := b.Start()
+	b = New(3, 1, "other")
+	b.entries[start.Hash()] = &Entry{Key: start.Hash(), Position: "0,2,0,1,0,1,1,1"}
+	assert.EqualError(b.Save(dir), "book "+dir+": position 0,2,0,1,0,1,1,1 has the key of "+start.AsCsv())
+	assert.EqualError(b.Set(start, nil), "position "+start.AsCsv()+" has the key of 0,2,0,1,0,1,1,1")
+	assert.EqualError(b.AddGame([]int{1}, 4, 0), "move 1: position "+start.AsCsv()+" has the key of 0,2,0,1,0,1,1,1")
+}
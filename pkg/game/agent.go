@@ -0,0 +1,223 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// ErrResign is returned by an Agent giving up the game
+var ErrResign = errors.New("resigned")
+
+// Agent is the extended player interface. A move can be cancelled
+// through the context, and an agent reports failure rather than
+// looping or returning an invalid hole.
+type Agent interface {
+	Move(ctx context.Context, pos *Position) (int, error)
+}
+
+// GameStarter is an optional Agent hook called before the first move,
+// start is from the agents side and side is 0 when it moves first
+type GameStarter interface {
+	NewGame(start *Position, side int)
+}
+
+// OpponentWatcher is an optional Agent hook called after each
+// opponent move, pos being the position moved from on the opponents side
+type OpponentWatcher interface {
+	OpponentMoved(pos *Position, hole int)
+}
+
+// GameEnder is an optional Agent hook called once the game is decided
+type GameEnder interface {
+	GameOver(result *GameResult, side int)
+}
+
+// playerAgent adapts a Player to an Agent
+type playerAgent struct {
+	p Player
+}
+
+// AsAgent adapts a Player so it can be used as an Agent.
+// A cancelled move returns the context error, the Player
+// finishing its move in the background.
+func AsAgent(p Player) Agent {
+	return &playerAgent{p: p}
+}
+
+// Move asks the Player to move unless the context is done first
+func (a *playerAgent) Move(ctx context.Context, pos *Position) (int, error) {
+	if ctx.Done() == nil {
+		// cannot be cancelled
+		return a.p.Move(pos), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	done := make(chan int, 1)
+	go func() {
+		done <- a.p.Move(pos)
+	}()
+	select {
+	case hole := <-done:
+		return hole, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// agentPlayer adapts an Agent to a Player
+type agentPlayer struct {
+	a Agent
+}
+
+// AsPlayer adapts an Agent so it can be used as a Player. A Player
+// cannot fail, so an error such as resigning or the end of the input
+// is logged and the lowest valid move played instead.
+func AsPlayer(a Agent) Player {
+	if pa, ok := a.(*playerAgent); ok {
+		return pa.p
+	}
+	return &agentPlayer{a: a}
+}
+
+// Move asks the Agent to move without a deadline
+func (p *agentPlayer) Move(pos *Position) int {
+	hole, err := p.a.Move(context.Background(), pos)
+	if err != nil {
+		hole = pos.ValidMoves()[0]
+		log.Errorf("%v, playing %d", err, hole)
+	}
+	return hole
+}
+
+// GameResult is the outcome of a game between two agents
+type GameResult struct {
+	// Final is the last position from the first agents side
+	Final *Position
+	// Score is the first agents home less the second agents
+	Score int
+	// Winner is the side of the winning agent, -1 for a draw
+	Winner int
+	// Resigned is set when the loser resigned
+	Resigned bool
+	// TimeOut is set when the loser ran out of time
+	TimeOut bool
+	// Plies is the number of moves made
+	Plies int
+}
+
+// AgentError reports an agent failing to make a valid move
+type AgentError struct {
+	Side int
+	Err  error
+}
+
+func (e *AgentError) Error() string {
+	return fmt.Sprintf("side %d: %v", e.Side+1, e.Err)
+}
+
+// Unwrap returns the agents error
+func (e *AgentError) Unwrap() error {
+	return e.Err
+}
+
+// PlayGame plays a game between two agents from a start position,
+// agents[0] moving first. The hooks of each agent are called as the
+// game progresses and observe, if not nil, is called after each move.
+// An agent resigning ends the game, any other failure stops it
+// with an AgentError.
+func PlayGame(ctx context.Context, agents [2]Agent, start *Position,
+	observe func(side int, pos *Position, hole int, t *Transition)) (*GameResult, error) {
+	return PlayTimedGame(ctx, agents, start, nil, observe)
+}
+
+// PlayTimedGame plays a game as PlayGame, each agent being passed its
+// clock, if clocks is not nil, and losing should it run out of time
+func PlayTimedGame(ctx context.Context, agents [2]Agent, start *Position, clocks *Clocks,
+	observe func(side int, pos *Position, hole int, t *Transition)) (*GameResult, error) {
+	for side, a := range agents {
+		if h, ok := a.(GameStarter); ok {
+			p := start
+			if side == 1 {
+				p = start.ChangePlayer()
+			}
+			h.NewGame(p, side)
+		}
+	}
+
+	result := &GameResult{Winner: -1}
+	pos, side := start, 0
+	for {
+		mctx, cancel := ctx, context.CancelFunc(func() {})
+		if clocks != nil {
+			mctx = WithClock(ctx, clocks.Clock(side))
+			if limit := clocks.Limit(side); limit > 0 {
+				mctx, cancel = context.WithTimeout(mctx, limit)
+			}
+		}
+		began := time.Now()
+		hole, err := agents[side].Move(mctx, pos)
+		cancel()
+		if clocks != nil && ctx.Err() == nil && !clocks.Used(side, time.Since(began)) {
+			result.TimeOut = true
+			result.Winner = 1 - side
+			break
+		}
+		if errors.Is(err, ErrResign) {
+			result.Resigned = true
+			result.Winner = 1 - side
+			break
+		}
+		if err != nil {
+			return nil, &AgentError{Side: side, Err: err}
+		}
+		t, err := pos.Play(hole)
+		if err != nil {
+			return nil, &AgentError{Side: side, Err: fmt.Errorf("move %d from %s: %v", hole, pos.AsCsv(), err)}
+		}
+		result.Plies++
+		if observe != nil {
+			observe(side, pos, hole, t)
+		}
+		if h, ok := agents[1-side].(OpponentWatcher); ok {
+			h.OpponentMoved(pos, hole)
+		}
+
+		if t.Result == EndOfGame {
+			pos = t.Next
+			break
+		}
+		if t.Result == EndOfTurn {
+			pos = t.Next.ChangePlayer()
+			side = 1 - side
+		} else {
+			pos = t.Next
+		}
+	}
+
+	// final position from the first agents side
+	if side == 1 {
+		pos = pos.ChangePlayer()
+	}
+	result.Final = pos
+	result.Score = pos.Score()
+	if !result.Resigned && !result.TimeOut {
+		switch {
+		case result.Score > 0:
+			result.Winner = 0
+		case result.Score < 0:
+			result.Winner = 1
+		}
+	}
+
+	for side, a := range agents {
+		if h, ok := a.(GameEnder); ok {
+			h.GameOver(result, side)
+		}
+	}
+	return result, nil
+}
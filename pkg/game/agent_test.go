@@ -0,0 +1,185 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptAgent plays fixed moves recording its hooks
+type scriptAgent struct {
+	moves    []int
+	events   []string
+	lastSide int
+}
+
+func (a *scriptAgent) Move(ctx context.Context, pos *Position) (int, error) {
+	if len(a.moves) == 0 {
+		return 0, ErrResign
+	}
+	hole := a.moves[0]
+	a.moves = a.moves[1:]
+	return hole, nil
+}
+
+func (a *scriptAgent) NewGame(start *Position, side int) {
+	a.events = append(a.events, "new")
+	a.lastSide = side
+}
+
+func (a *scriptAgent) OpponentMoved(pos *Position, hole int) {
+	a.events = append(a.events, "opponent")
+}
+
+func (a *scriptAgent) GameOver(result *GameResult, side int) {
+	a.events = append(a.events, "over")
+}
+
+// slowPlayer is a legacy Player which takes too long
+type slowPlayer struct{}
+
+func (slowPlayer) Move(pos *Position) int {
+	time.Sleep(time.Second)
+	return 1
+}
+
+func TestPlayGame(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 1)
+
+	// the second repeats then ends the turn,
+	// the first repeats to the end of the game
+	first := &scriptAgent{moves: []int{3, 1, 2, 1}}
+	second := &scriptAgent{moves: []int{1, 2}}
+	plies := 0
+	result, err := PlayGame(context.Background(), [2]Agent{first, second}, StartPosition(),
+		func(side int, pos *Position, hole int, tr *Transition) {
+			plies++
+		})
+	assert.Nil(err)
+	assert.Equal(plies, result.Plies)
+	assert.Equal(6, result.Plies)
+	assert.Equal(result.Final.Score(), result.Score)
+	assert.Equal(2, result.Score)
+	assert.Equal(0, result.Winner)
+	assert.False(result.Resigned)
+	assert.Equal([]string{"new", "opponent", "opponent", "opponent", "opponent", "over"}, second.events)
+	assert.Equal([]string{"new", "opponent", "opponent", "over"}, first.events)
+	assert.Equal(1, second.lastSide)
+
+	// resigning loses
+	first = &scriptAgent{}
+	second = &scriptAgent{}
+	result, err = PlayGame(context.Background(), [2]Agent{first, second}, StartPosition(), nil)
+	assert.Nil(err)
+	assert.True(result.Resigned)
+	assert.Equal(1, result.Winner)
+
+	// an invalid move stops the game
+	_, err = PlayGame(context.Background(), [2]Agent{&scriptAgent{moves: []int{4}}, second}, StartPosition(), nil)
+	var ae *AgentError
+	assert.True(errors.As(err, &ae))
+	assert.Equal(0, ae.Side)
+}
+
+func TestAsAgent(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 1)
+
+	a := AsAgent(&RandomPlayer{Quiet: true})
+	hole, err := a.Move(context.Background(), StartPosition())
+	assert.Nil(err)
+	assert.Contains(StartPosition().ValidMoves(), hole)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = AsAgent(slowPlayer{}).Move(ctx, StartPosition())
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestAsPlayer(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 1)
+
+	p := AsPlayer(&scriptAgent{moves: []int{2}})
+	assert.Equal(2, p.Move(StartPosition()))
+	// a resignation plays the lowest valid move
+	assert.Equal(1, p.Move(StartPosition()))
+
+	// a player adapted both ways is itself
+	r := &RandomPlayer{}
+	assert.Equal(r, AsPlayer(AsAgent(r)))
+
+	// the console is still a player
+	c, err := CreatePlayer(map[string]string{"type": "console", "name": "test"})
+	assert.Nil(err)
+	assert.Equal("test", c.(*agentPlayer).a.(*ConsolePlayer).Name)
+}
+
+func TestConsolePlayer(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 1)
+
+	out := &strings.Builder{}
+	p := &ConsolePlayer{Name: "test", In: strings.NewReader("x\n9\n2\nresign\n3"), Out: out}
+	hole, err := p.Move(context.Background(), StartPosition())
+	assert.Nil(err)
+	assert.Equal(2, hole)
+	assert.Equal("test >  x not valid\n---\n 9 not valid\n---\n", out.String())
+
+	_, err = p.Move(context.Background(), StartPosition())
+	assert.Equal(ErrResign, err)
+
+	// the last line needs no newline
+	hole, err = p.Move(context.Background(), StartPosition())
+	assert.Nil(err)
+	assert.Equal(3, hole)
+
+	// no more input fails rather than waiting forever
+	_, err = p.Move(context.Background(), StartPosition())
+	assert.Equal(io.EOF, err)
+}
+
+func TestConsoleCoach(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(6, 4)
+
+	assert.Empty(warnings(StartPosition(), 4))
+	assert.Equal([]string{"gives up the repeat turn of 4"}, warnings(StartPosition(), 1))
+	assert.Equal([]string{"hands over a capture of 5 stones by their 3", "gives up the repeat turn of 4"},
+		warnings(StartPosition(), 6))
+
+	// a hint, then a warned move declined and confirmed
+	out := &strings.Builder{}
+	p := &ConsolePlayer{Name: "test", In: strings.NewReader("?\n6\nn\n6\ny\nhint\n"), Out: out,
+		Coach: &scriptAgent{moves: []int{4}}, Warn: true}
+	hole, err := p.Move(context.Background(), StartPosition())
+	assert.Nil(err)
+	assert.Equal(6, hole)
+	assert.Equal("test >  hint 4\ntest >  6 hands over a capture of 5 stones by their 3 and gives up the repeat turn of 4, play it? (y/n) test >  "+
+		"6 hands over a capture of 5 stones by their 3 and gives up the repeat turn of 4, play it? (y/n) ", out.String())
+
+	// the coach has no more moves
+	_, err = p.Move(context.Background(), StartPosition())
+	assert.EqualError(err, "coach: resigned")
+
+	p, err = newConsolePlayerFor(map[string]string{"type": "console", "coach": "random"})
+	assert.Nil(err)
+	assert.IsType(&playerAgent{}, p.Coach)
+	_, err = newConsolePlayerFor(map[string]string{"type": "console", "coach": "nobody"})
+	assert.EqualError(err, `coach: invalid player type "nobody", must be one of: console, random`)
+}
+
+// newConsolePlayerFor creates a console player from a configuration
+func newConsolePlayerFor(conf map[string]string) (*ConsolePlayer, error) {
+	a, err := CreateAgent(conf)
+	if err != nil {
+		return nil, err
+	}
+	return a.(*ConsolePlayer), nil
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeControl is a game clock of a base time plus an increment after
+// each move, and or a budget for each move
+type TimeControl struct {
+	Base, Increment, MoveTime time.Duration
+}
+
+// Clock is the time an agent has, passed in the context of a move
+type Clock struct {
+	// Remaining is the time left on the game clock, zero for none
+	Remaining time.Duration
+	Increment time.Duration
+	// MoveTime is the budget for each move, zero for none
+	MoveTime time.Duration
+}
+
+type clockKey struct{}
+
+// WithClock passes a clock to an agent
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockFrom finds the clock passed to an agent
+func ClockFrom(ctx context.Context) (Clock, bool) {
+	c, ok := ctx.Value(clockKey{}).(Clock)
+	return c, ok
+}
+
+// Budget is the time to spend on a move, a twentieth of the
+// remaining time plus most of the increment, no more than the
+// move time and leaving a margin on the clock. Zero is no limit.
+func (c Clock) Budget() time.Duration {
+	if c.Remaining <= 0 {
+		return c.MoveTime
+	}
+	b := c.Remaining/20 + c.Increment*3/4
+	if c.MoveTime > 0 && c.MoveTime < b {
+		b = c.MoveTime
+	}
+	if limit := c.Remaining * 9 / 10; b > limit {
+		b = limit
+	}
+	return b
+}
+
+// Clocks are the game clocks of both sides
+type Clocks struct {
+	Control   TimeControl
+	Remaining [2]time.Duration
+}
+
+// NewClocks starts both sides with the base time
+func NewClocks(tc TimeControl) *Clocks {
+	return &Clocks{Control: tc, Remaining: [2]time.Duration{tc.Base, tc.Base}}
+}
+
+// Clock is that of a side to pass to its agent
+func (c *Clocks) Clock(side int) Clock {
+	return Clock{Remaining: c.Remaining[side], Increment: c.Control.Increment, MoveTime: c.Control.MoveTime}
+}
+
+// Limit is the longest a side may take over its move, zero for none
+func (c *Clocks) Limit(side int) time.Duration {
+	if c.Control.Base <= 0 {
+		return 0
+	}
+	return c.Remaining[side]
+}
+
+// Used charges a side for a move and adds the increment,
+// false once the side has run out of time
+func (c *Clocks) Used(side int, d time.Duration) bool {
+	if c.Control.Base <= 0 {
+		return true
+	}
+	c.Remaining[side] -= d
+	if c.Remaining[side] <= 0 {
+		c.Remaining[side] = 0
+		return false
+	}
+	c.Remaining[side] += c.Control.Increment
+	return true
+}
+
+// String shows the remaining time of each side
+func (c *Clocks) String() string {
+	return fmt.Sprintf("clock  p1 %s  p2 %s", FormatClock(c.Remaining[0]), FormatClock(c.Remaining[1]))
+}
+
+// FormatClock shows a time as minutes, seconds and tenths
+func FormatClock(d time.Duration) string {
+	tenths := d.Milliseconds() / 100
+	return fmt.Sprintf("%d:%02d.%d", tenths/600, tenths/10%60, tenths%10)
+}
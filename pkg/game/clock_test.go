@@ -0,0 +1,58 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(time.Duration(0), Clock{}.Budget())
+	assert.Equal(time.Second, Clock{MoveTime: time.Second}.Budget())
+	// a twentieth plus most of the increment
+	assert.Equal(3*time.Second+750*time.Millisecond, Clock{Remaining: time.Minute, Increment: time.Second}.Budget())
+	assert.Equal(time.Second, Clock{Remaining: time.Minute, MoveTime: time.Second}.Budget())
+	// leaving a margin
+	assert.Equal(90*time.Millisecond, Clock{Remaining: 100 * time.Millisecond, Increment: time.Second}.Budget())
+
+	ctx := WithClock(context.Background(), Clock{MoveTime: time.Second})
+	c, ok := ClockFrom(ctx)
+	assert.True(ok)
+	assert.Equal(time.Second, c.MoveTime)
+	_, ok = ClockFrom(context.Background())
+	assert.False(ok)
+
+	clocks := NewClocks(TimeControl{Base: time.Minute, Increment: time.Second})
+	assert.True(clocks.Used(0, 10*time.Second))
+	assert.Equal(51*time.Second, clocks.Remaining[0])
+	assert.Equal(time.Minute, clocks.Limit(1))
+	assert.Equal("clock  p1 0:51.0  p2 1:00.0", clocks.String())
+	assert.False(clocks.Used(1, 2*time.Minute))
+	assert.Equal(time.Duration(0), clocks.Remaining[1])
+
+	// a move time alone is not enforced
+	clocks = NewClocks(TimeControl{MoveTime: time.Millisecond})
+	assert.Equal(time.Duration(0), clocks.Limit(0))
+	assert.True(clocks.Used(0, time.Second))
+
+	assert.Equal("61:01.2", FormatClock(time.Hour+time.Minute+1234*time.Millisecond))
+}
+
+func TestPlayTimedGame(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 1)
+
+	// the slow second player loses on time
+	first := &scriptAgent{moves: []int{3, 1, 2, 1}}
+	clocks := NewClocks(TimeControl{Base: 100 * time.Millisecond})
+	result, err := PlayTimedGame(context.Background(), [2]Agent{first, AsAgent(slowPlayer{})}, StartPosition(), clocks, nil)
+	assert.Nil(err)
+	assert.True(result.TimeOut)
+	assert.Equal(0, result.Winner)
+	assert.Equal(1, result.Plies)
+	assert.Equal(time.Duration(0), clocks.Remaining[1])
+}
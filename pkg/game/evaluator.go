@@ -0,0 +1,205 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Evaluator scores a position from the side to move, higher being better
+type Evaluator interface {
+	Evaluate(pos *Position) float64
+}
+
+// EvaluatorFunc adapts a function to an Evaluator
+type EvaluatorFunc func(pos *Position) float64
+
+// Evaluate calls the function
+func (f EvaluatorFunc) Evaluate(pos *Position) float64 {
+	return f(pos)
+}
+
+// StoreEvaluator is the near home less the far home
+func StoreEvaluator(pos *Position) float64 {
+	return float64(pos.Score())
+}
+
+// MaterialEvaluator is the stones in the near home and holes
+// less those of the far side
+func MaterialEvaluator(pos *Position) float64 {
+	near, far := 0, 0
+	for _, v := range pos.near().Items {
+		near += v
+	}
+	for _, v := range pos.far().Items {
+		far += v
+	}
+	return float64(near - far)
+}
+
+// MobilityEvaluator is the number of valid moves less those of the opponent
+func MobilityEvaluator(pos *Position) float64 {
+	return float64(len(pos.ValidMoves()) - len(pos.ChangePlayer().ValidMoves()))
+}
+
+// CaptureEvaluator is the most stones a move could capture
+// less the most the opponent could capture
+func CaptureEvaluator(pos *Position) float64 {
+	return float64(threats(pos).capture - threats(pos.ChangePlayer()).capture)
+}
+
+// RepeatEvaluator is the number of moves giving a repeat turn
+// less those of the opponent
+func RepeatEvaluator(pos *Position) float64 {
+	return float64(threats(pos).repeats - threats(pos.ChangePlayer()).repeats)
+}
+
+// threat summarises the moves available from a position
+type threat struct {
+	capture int
+	repeats int
+}
+
+// threats plays each valid move looking for captures and repeats
+func threats(pos *Position) (t threat) {
+	for _, hole := range pos.ValidMoves() {
+		tr, err := pos.Play(hole)
+		if err != nil {
+			continue
+		}
+		if tr.Steal && tr.StealCount > t.capture {
+			t.capture = tr.StealCount
+		}
+		if tr.Result == RepeatTurn {
+			t.repeats++
+		}
+	}
+	return
+}
+
+// Term is a weighted evaluator within a Weighted sum
+type Term struct {
+	Name   string
+	Weight float64
+	Eval   Evaluator
+}
+
+// Weighted is the sum of weighted evaluators
+type Weighted struct {
+	Terms []Term
+}
+
+// NewWeighted combines built-in evaluators by name, in name order
+func NewWeighted(weights map[string]float64) (*Weighted, error) {
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := &Weighted{}
+	for _, name := range names {
+		e, ok := builtinEvaluators[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid evaluator term %q, must be one of: %s", name, strings.Join(builtinNames(), ", "))
+		}
+		w.Terms = append(w.Terms, Term{Name: name, Weight: weights[name], Eval: e})
+	}
+	return w, nil
+}
+
+// Evaluate sums the weighted terms
+func (w *Weighted) Evaluate(pos *Position) float64 {
+	v := 0.0
+	for _, t := range w.Terms {
+		v += t.Weight * t.Eval.Evaluate(pos)
+	}
+	return v
+}
+
+// Weights are the weights of each term by name
+func (w *Weighted) Weights() map[string]float64 {
+	weights := make(map[string]float64, len(w.Terms))
+	for _, t := range w.Terms {
+		weights[t.Name] = t.Weight
+	}
+	return weights
+}
+
+var builtinEvaluators = map[string]Evaluator{
+	"store":    EvaluatorFunc(StoreEvaluator),
+	"material": EvaluatorFunc(MaterialEvaluator),
+	"mobility": EvaluatorFunc(MobilityEvaluator),
+	"captures": EvaluatorFunc(CaptureEvaluator),
+	"repeats":  EvaluatorFunc(RepeatEvaluator),
+}
+
+// builtinNames lists the built-in evaluators
+func builtinNames() []string {
+	names := make([]string, 0, len(builtinEvaluators))
+	for name := range builtinEvaluators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var evaluators = make(map[string]Evaluator)
+
+// RegisterEvaluator names an evaluator, such as weights from config,
+// replacing any registered before
+func RegisterEvaluator(name string, e Evaluator) error {
+	if _, ok := builtinEvaluators[name]; ok {
+		return fmt.Errorf("evaluator %s is built in", name)
+	}
+	evaluators[name] = e
+	return nil
+}
+
+// RegisterWeights names a weighted sum of built-in evaluators
+func RegisterWeights(name string, weights map[string]float64) error {
+	w, err := NewWeighted(weights)
+	if err != nil {
+		return err
+	}
+	return RegisterEvaluator(name, w)
+}
+
+// RegisterWeightsFrom names the weighted evaluators of the evaluators
+// section of a config, such as a weights file written by tune,
+// returning an error for each it rejects
+func RegisterWeightsFrom(v *viper.Viper) []error {
+	var errs []error
+	for name := range v.GetStringMap("evaluators") {
+		weights := make(map[string]float64)
+		for term := range v.GetStringMap("evaluators." + name) {
+			weights[term] = v.GetFloat64("evaluators." + name + "." + term)
+		}
+		if err := RegisterWeights(name, weights); err != nil {
+			errs = append(errs, fmt.Errorf("evaluator %s: %v", name, err))
+		}
+	}
+	return errs
+}
+
+// Evaluators lists the built-in and registered evaluators by name
+func Evaluators() []string {
+	names := builtinNames()
+	for name := range evaluators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEvaluator finds a built-in or registered evaluator
+func LookupEvaluator(name string) (Evaluator, error) {
+	if e, ok := builtinEvaluators[name]; ok {
+		return e, nil
+	}
+	if e, ok := evaluators[name]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("invalid evaluator %q, must be one of: %s", name, strings.Join(Evaluators(), ", "))
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluators(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 4)
+
+	// near has a capture of 8 by hole 2 and a repeat by hole 3,
+	// far has no capture and a repeat by hole 1
+	pos := CreatePosition(2, 0, 1, 3, 1, 1, 4, 8)
+	assert.Equal(1.0, StoreEvaluator(pos))
+	assert.Equal(6.0-14.0, MaterialEvaluator(pos))
+	assert.Equal(2.0-3.0, MobilityEvaluator(pos))
+	assert.Equal(8.0, CaptureEvaluator(pos))
+	assert.Equal(0.0, RepeatEvaluator(pos))
+
+	w, err := NewWeighted(map[string]float64{"store": 2, "captures": 0.5})
+	assert.Nil(err)
+	assert.Equal(2*1.0+0.5*8.0, w.Evaluate(pos))
+	assert.Equal(map[string]float64{"store": 2, "captures": 0.5}, w.Weights())
+
+	_, err = NewWeighted(map[string]float64{"bogus": 1})
+	assert.EqualError(err, `invalid evaluator term "bogus", must be one of: captures, material, mobility, repeats, store`)
+
+	assert.Nil(RegisterWeights("test", map[string]float64{"store": 1}))
+	e, err := LookupEvaluator("test")
+	assert.Nil(err)
+	assert.Equal(1.0, e.Evaluate(pos))
+	assert.NotNil(RegisterEvaluator("store", w))
+	_, err = LookupEvaluator("unknown")
+	assert.EqualError(err, `invalid evaluator "unknown", must be one of: captures, material, mobility, repeats, store, test`)
+}
+
+func TestRegisterWeightsFrom(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 4)
+
+	v := viper.New()
+	v.Set("evaluators.tuned.store", 1.5)
+	v.Set("evaluators.tuned.captures", 0.25)
+	v.Set("evaluators.broken.luck", 1)
+	errs := RegisterWeightsFrom(v)
+	if assert.Len(errs, 1) {
+		assert.EqualError(errs[0], `evaluator broken: invalid evaluator term "luck", must be one of: captures, material, mobility, repeats, store`)
+	}
+	e, err := LookupEvaluator("tuned")
+	assert.Nil(err)
+	assert.Equal(map[string]float64{"store": 1.5, "captures": 0.25}, e.(*Weighted).Weights())
+	_, err = LookupEvaluator("broken")
+	assert.NotNil(err)
+}
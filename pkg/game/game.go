@@ -87,13 +87,25 @@ func CreatePositionCsv(csv string) (p *Position) {
 func (p *Position) AsCsv() string {
 	var s []string
 	for r := range []int{0, 1} {
-		for i := 0; i < WIDTH()+1; i++ {
+		for i := 0; i <= p.width(); i++ {
 			s = append(s, strconv.Itoa(p.Row[r].Items[i]))
 		}
 	}
 	return strings.Join(s, ",")
 }
 
+// Hash is an FNV-1a hash of the stones of a Position, near side first
+func (p *Position) Hash() uint64 {
+	h := uint64(14695981039346656037)
+	for r := range p.Row {
+		for _, v := range p.Row[r].Items {
+			h ^= uint64(v)
+			h *= 1099511628211
+		}
+	}
+	return h
+}
+
 // near is a convenience helper
 func (p *Position) near() *Side {
 	return &p.Row[0]
@@ -145,10 +157,17 @@ func (p *Position) IsValid() (bool, int) {
 	return sum == correct, correct - sum
 }
 
+// width is that of the board of a position, so positions
+// can be played without the game dimensions
+func (p *Position) width() int {
+	return len(p.Row[0].Items) - 1
+}
+
 // ValidMoves returns an array of all valid moves
 func (p *Position) ValidMoves() (holes []int) {
-	holes = make([]int, 0, WIDTH()-1)
-	for i := 1; i <= WIDTH(); i++ {
+	w := p.width()
+	holes = make([]int, 0, w)
+	for i := 1; i <= w; i++ {
 		if p.near().Items[i] > 0 {
 			holes = append(holes, i)
 		}
@@ -164,6 +183,21 @@ func max(x, y int) int {
 	return y
 }
 
+// Rules flags the rule variations implemented by Move
+type Rules uint8
+
+const (
+	// RuleRepeatTurn when last stone lands in own home
+	RuleRepeatTurn Rules = 1 << iota
+	// RuleSkipHome skips the opponent home when sowing
+	RuleSkipHome
+	// RuleSteal captures opposite stones on a single stone
+	RuleSteal
+)
+
+// StandardRules are the rules implemented by Move
+const StandardRules = RuleRepeatTurn | RuleSkipHome | RuleSteal
+
 // MoveResult captures who goes next
 type MoveResult int8
 
@@ -178,44 +212,82 @@ const (
 	EndOfGame MoveResult = 2
 )
 
+// String returns the name of a MoveResult
+func (r MoveResult) String() string {
+	switch r {
+	case EndOfTurn:
+		return "EndOfTurn"
+	case RepeatTurn:
+		return "RepeatTurn"
+	case EndOfGame:
+		return "EndOfGame"
+	}
+	return "BadMove"
+}
+
+// Transition details the effect of a single move
+type Transition struct {
+	// Next is the position after the move, before any change of player
+	Next *Position
+	// Delta is the change of stones from sowing, excluding any steal
+	Delta *Position
+	// Result determines who goes next
+	Result MoveResult
+	// Steal is set when the last stone captured the opposite hole
+	Steal bool
+	// StealRow, StealHole and StealCount locate the captured stones
+	StealRow, StealHole, StealCount int
+}
+
 // Move creates a new position given a players move
 func (p *Position) Move(hole int) (*Position, *Position, MoveResult, error) {
+	t, err := p.Play(hole)
+	if err != nil {
+		return p, nil, BadMove, err
+	}
+	return t.Next, t.Delta, t.Result, nil
+}
+
+// Play makes a move returning the details of the transition
+func (p *Position) Play(hole int) (*Transition, error) {
 	// validate in range
-	if hole < 1 || hole > WIDTH() {
-		return p, nil, BadMove, errors.New("hole not in range")
+	if hole < 1 || hole > p.width() {
+		return nil, errors.New("hole not in range")
 	}
 
 	// validate hole has stones
 	stones := p.near().Items[hole]
 	if stones == 0 {
-		return p, nil, BadMove, errors.New("invalid move")
+		return nil, errors.New("invalid move")
 	}
 
 	// create delta position
-	delta, lastRow, lastHole := deltaPosition(hole, stones)
+	delta, lastRow, lastHole := deltaPosition(p.width(), hole, stones)
 	// fmt.Printf("deltaPosition lastRow:%d, lastHole:%d\n", lastRow, lastHole)
 	// combine
-	result := p.add(delta)
+	t := &Transition{Next: p.add(delta), Delta: delta}
 
 	// determina result from last position
-	moveResult := EndOfTurn
+	t.Result = EndOfTurn
 	if lastHole == 0 {
-		moveResult = RepeatTurn
+		t.Result = RepeatTurn
 	}
 
 	// check for steal
-	if isSteal, opRow, opHole, opCount := result.IsSteal(lastRow, lastHole); isSteal {
+	if isSteal, opRow, opHole, opCount := t.Next.IsSteal(lastRow, lastHole); isSteal {
 		// create steal position
-		steal := stealPosition(lastRow, lastHole, opRow, opHole, opCount)
+		steal := stealPosition(p.width(), lastRow, lastHole, opRow, opHole, opCount)
 		// apply
-		result = result.add(steal)
+		t.Next = t.Next.add(steal)
+		t.Steal = true
+		t.StealRow, t.StealHole, t.StealCount = opRow, opHole, opCount
 	}
 
-	if result.IsGameEnd() {
-		moveResult = EndOfGame
+	if t.Next.IsGameEnd() {
+		t.Result = EndOfGame
 	}
 
-	return result, delta, moveResult, nil
+	return t, nil
 }
 
 // IsGameEnd checks for end of game
@@ -232,6 +304,11 @@ func (p *Position) IsGameEnd() bool {
 	return (near == 0 || far == 0)
 }
 
+// Score is the near home less the far home
+func (p *Position) Score() int {
+	return p.near().Items[0] - p.far().Items[0]
+}
+
 // IsSteal determines if last position is a steal
 func (p *Position) IsSteal(row int, hole int) (steal bool, opRow int, opHole int, opCount int) {
 	if hole == 0 {
@@ -240,7 +317,7 @@ func (p *Position) IsSteal(row int, hole int) (steal bool, opRow int, opHole int
 	// check if last position resulted in a single stone
 	// and opposite isn't empty
 	opRow = (row + 1) % 2
-	opHole = WIDTH() + 1 - hole
+	opHole = p.width() + 1 - hole
 	opCount = p.Row[opRow].Items[opHole]
 	if opCount > 0 && p.Row[row].Items[hole] == 1 {
 		steal = true
@@ -250,9 +327,9 @@ func (p *Position) IsSteal(row int, hole int) (steal bool, opRow int, opHole int
 
 // add one position to another
 func (p *Position) add(delta *Position) (pos *Position) {
-	pos = ZeroPosition()
+	pos = zeroPosition(p.width())
 	for row := 0; row < 2; row++ {
-		for hole := 0; hole <= WIDTH(); hole++ {
+		for hole := 0; hole <= p.width(); hole++ {
 			pos.Row[row].Items[hole] = p.Row[row].Items[hole] +
 				delta.Row[row].Items[hole]
 		}
@@ -279,15 +356,15 @@ func (p *Position) addHole(start int, count int, value int) (skip bool, row int,
 		}
 		count = count - start // reduce count
 		row++                 // on to next row
-		start = WIDTH() + 1   // +1 for zero index
+		start = p.width() + 1 // +1 for zero index
 	}
 	return false, row % 2, count
 }
 
 // ChangePlayer create a new position from other perspective
 func (p *Position) ChangePlayer() (s *Position) {
-	bar0 := make([]int, WIDTH()+1)
-	bar1 := make([]int, WIDTH()+1)
+	bar0 := make([]int, p.width()+1)
+	bar1 := make([]int, p.width()+1)
 	copy(bar0, p.far().Items)
 	copy(bar1, p.near().Items)
 
@@ -300,8 +377,8 @@ func (p *Position) ChangePlayer() (s *Position) {
 // deltaPosition creates a position with each hole
 // having the change of stones required
 // it return the final row and hole populated
-func deltaPosition(h int, count int) (p *Position, row int, hole int) {
-	p = ZeroPosition()
+func deltaPosition(width int, h int, count int) (p *Position, row int, hole int) {
+	p = zeroPosition(width)
 	p.near().Items[h] = -count
 	for i := 1; count > 0; i, count = i+1, count-1 {
 		var skip bool
@@ -315,8 +392,8 @@ func deltaPosition(h int, count int) (p *Position, row int, hole int) {
 
 // stealPosition creates a position with each hole
 // having the change of stones required for a steal
-func stealPosition(r int, h int, opRow int, opHole int, opCount int) (p *Position) {
-	p = ZeroPosition()
+func stealPosition(width int, r int, h int, opRow int, opHole int, opCount int) (p *Position) {
+	p = zeroPosition(width)
 	p.Row[opRow].Items[opHole] = -opCount
 	p.Row[r].Items[h] = -1
 	p.near().Items[0] = opCount + 1
@@ -341,8 +418,13 @@ func StartPosition() (p *Position) {
 
 // ZeroPosition creates an empty position
 func ZeroPosition() (p *Position) {
-	bar0 := make([]int, WIDTH()+1)
-	bar1 := make([]int, WIDTH()+1)
+	return zeroPosition(WIDTH())
+}
+
+// zeroPosition creates an empty position of a width
+func zeroPosition(width int) (p *Position) {
+	bar0 := make([]int, width+1)
+	bar1 := make([]int, width+1)
 
 	p = &Position{}
 	p.Row[0] = Side{Items: bar0}
@@ -42,6 +42,32 @@ func TestNearFar(t *testing.T) {
 	assert.True(cmp.Equal(f, p.far()))
 }
 
+func TestHash(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 4)
+
+	p := CreatePosition(1, 3, 5, 7, 2, 4, 6, 8)
+	assert.Equal(p.Hash(), CreatePosition(1, 3, 5, 7, 2, 4, 6, 8).Hash())
+	assert.NotEqual(p.Hash(), p.ChangePlayer().Hash())
+	assert.NotEqual(p.Hash(), CreatePosition(1, 3, 5, 7, 2, 4, 6, 9).Hash())
+}
+
+func TestPlayOwnWidth(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 4)
+	p := CreatePosition(0, 0, 1, 3, 0, 4, 4, 8)
+	want, err := p.Play(2)
+	assert.Nil(err)
+
+	// positions keep their width whatever the game
+	DefineGame(6, 4)
+	got, err := p.Play(2)
+	assert.Nil(err)
+	assert.True(cmp.Equal(want, got))
+	assert.Equal([]int{2, 3}, p.ValidMoves())
+	assert.True(cmp.Equal(want.Next.ChangePlayer(), got.Next.ChangePlayer()))
+}
+
 func TestCsv(t *testing.T) {
 	assert := assert.New(t)
 	DefineGame(3, 4)
@@ -55,3 +81,80 @@ func TestCsv(t *testing.T) {
 	assert.Equal(p, clone)
 	assert.True(cmp.Equal(p, clone))
 }
+
+func TestPlaySteal(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(3, 4)
+
+	p := CreatePosition(0, 0, 1, 3, 0, 4, 4, 4)
+	tr, err := p.Play(2)
+	assert.Nil(err)
+	assert.True(tr.Steal)
+	assert.Equal(1, tr.StealRow)
+	assert.Equal(3, tr.StealHole)
+	assert.Equal(4, tr.StealCount)
+	assert.Equal("5,0,0,3,0,4,4,0", tr.Next.AsCsv())
+	assert.Equal(EndOfTurn, tr.Result)
+
+	tr, err = p.Play(3)
+	assert.Nil(err)
+	assert.False(tr.Steal)
+	assert.Equal(RepeatTurn, tr.Result)
+
+	_, err = p.Play(1)
+	assert.NotNil(err)
+}
+
+func TestEdgeCaseVectors(t *testing.T) {
+	assert := assert.New(t)
+
+	vectors, err := EdgeCaseVectors()
+	assert.Nil(err)
+	tags := make(map[string][]string)
+	for _, v := range vectors {
+		tags[v.Name] = v.Tags
+	}
+	assert.Equal([]string{"repeat"}, tags["start repeat"])
+	assert.Equal([]string{"skip-home"}, tags["skip opponent home"])
+	assert.Equal([]string{"skip-home", "lap"}, tags["lap the board"])
+	assert.Equal([]string{"repeat", "skip-home", "lap"}, tags["lap repeat"])
+	assert.Equal([]string{"steal"}, tags["steal"])
+	assert.Equal([]string{"steal", "steal-far"}, tags["steal far side"])
+	assert.Equal([]string{"skip-home", "lap", "steal"}, tags["lap steal"])
+	assert.Equal([]string{}, tags["no steal opposite empty"])
+	assert.Equal([]string{"end"}, tags["end of game"])
+	assert.Equal([]string{"steal", "end"}, tags["end of game steal"])
+}
+
+func TestSampleVectors(t *testing.T) {
+	assert := assert.New(t)
+
+	a, err := SampleVectors([]int{3, 6}, []int{2, 4}, 2, 1)
+	assert.Nil(err)
+	b, err := SampleVectors([]int{3, 6}, []int{2, 4}, 2, 1)
+	assert.Nil(err)
+	assert.True(cmp.Equal(a, b))
+
+	// each game ends with the end of game
+	ends := 0
+	for _, v := range a {
+		if v.Result == EndOfGame {
+			ends++
+		}
+	}
+	assert.Equal(8, ends)
+}
+
+func TestBuildSuiteLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := BuildSuite([]int{3, 0}, []int{2}, 1, 1)
+	assert.EqualError(err, "width 0 must be at least 1")
+	_, err = BuildSuite([]int{3}, []int{0}, 1, 1)
+	assert.EqualError(err, "stones 0 must be at least 1")
+
+	// a start without stones has no moves to sample
+	v, err := SampleVectors([]int{3}, []int{0}, 1, 1)
+	assert.Nil(err)
+	assert.Empty(v)
+}
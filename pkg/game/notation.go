@@ -0,0 +1,57 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatLine writes moves played from a position in the game notation,
+// a word for each turn with the moves of a turn joined by +, so a
+// move giving a repeat turn is followed by +. For example 3+6 4 1+2
+// is 3 with a repeat turn then 6, 4 for the opponent, then 1 and 2.
+func FormatLine(pos *Position, holes []int) (string, error) {
+	var words []string
+	var turn strings.Builder
+	for i, hole := range holes {
+		t, err := pos.Play(hole)
+		if err != nil {
+			return "", fmt.Errorf("move %d of %d from %s: %v", i+1, hole, pos.AsCsv(), err)
+		}
+		turn.WriteString(strconv.Itoa(hole))
+		switch t.Result {
+		case RepeatTurn:
+			turn.WriteString("+")
+			pos = t.Next
+			continue
+		case EndOfTurn:
+			pos = t.Next.ChangePlayer()
+		default:
+			pos = t.Next
+		}
+		words = append(words, turn.String())
+		turn.Reset()
+		if t.Result == EndOfGame && i < len(holes)-1 {
+			return "", fmt.Errorf("move %d of %d after the end of the game", i+2, holes[i+1])
+		}
+	}
+	if turn.Len() > 0 {
+		words = append(words, turn.String())
+	}
+	return strings.Join(words, " "), nil
+}
+
+// ParseLine reads the moves of a line in the game notation
+func ParseLine(line string) ([]int, error) {
+	var holes []int
+	for _, word := range strings.Fields(line) {
+		for _, m := range strings.Split(strings.TrimSuffix(word, "+"), "+") {
+			hole, err := strconv.Atoi(m)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a move", m)
+			}
+			holes = append(holes, hole)
+		}
+	}
+	return holes, nil
+}
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLine(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(6, 4)
+
+	// 4 sows into the home for a repeat turn
+	line, err := FormatLine(StartPosition(), []int{4, 3, 1, 2})
+	assert.Nil(err)
+	assert.Equal("4+3 1 2", line)
+	holes, err := ParseLine(line)
+	assert.Nil(err)
+	assert.Equal([]int{4, 3, 1, 2}, holes)
+
+	// a line may stop on a repeat turn
+	line, err = FormatLine(StartPosition(), []int{4})
+	assert.Nil(err)
+	assert.Equal("4+", line)
+	holes, err = ParseLine(line)
+	assert.Nil(err)
+	assert.Equal([]int{4}, holes)
+
+	_, err = FormatLine(StartPosition(), []int{4, 4})
+	assert.EqualError(err, "move 2 of 4 from 1,5,5,5,0,4,4,0,4,4,4,4,4,4: invalid move")
+	_, err = ParseLine("4+x")
+	assert.EqualError(err, `"x" is not a move`)
+
+	// nothing after the end of the game
+	DefineGame(3, 1)
+	_, err = FormatLine(StartPosition(), []int{1, 2, 1, 2, 1})
+	assert.EqualError(err, "move 5 of 1 after the end of the game")
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParamType is the type of a player option
+type ParamType int
+
+const (
+	// StringParam is any text
+	StringParam ParamType = iota
+	// IntParam is a whole number
+	IntParam
+	// FloatParam is a decimal number
+	FloatParam
+	// BoolParam is true or false
+	BoolParam
+)
+
+// String returns the name of a ParamType
+func (t ParamType) String() string {
+	switch t {
+	case IntParam:
+		return "int"
+	case FloatParam:
+		return "float"
+	case BoolParam:
+		return "bool"
+	}
+	return "string"
+}
+
+// Param describes a single player option
+type Param struct {
+	Name        string
+	Type        ParamType
+	Default     string
+	Description string
+	// Min and Max bound numeric options, unless equal
+	Min, Max float64
+}
+
+// Range describes the bounds of a numeric option
+func (p Param) Range() string {
+	if p.Min == p.Max {
+		return ""
+	}
+	return strconv.FormatFloat(p.Min, 'f', -1, 64) + ".." + strconv.FormatFloat(p.Max, 'f', -1, 64)
+}
+
+// defaultValue is the Default or the zero value of the type
+func (p Param) defaultValue() string {
+	if p.Default != "" || p.Type == StringParam {
+		return p.Default
+	}
+	if p.Type == BoolParam {
+		return "false"
+	}
+	return "0"
+}
+
+// parse converts and checks a single value
+func (p Param) parse(s string) (interface{}, error) {
+	var v interface{}
+	var f float64
+	switch p.Type {
+	case IntParam:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %q is not an int", p.Name, s)
+		}
+		v, f = i, float64(i)
+	case FloatParam:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %q is not a float", p.Name, s)
+		}
+		v, f = x, x
+	case BoolParam:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %q is not a bool", p.Name, s)
+		}
+		return b, nil
+	default:
+		return s, nil
+	}
+	if p.Min != p.Max && (f < p.Min || f > p.Max) {
+		return nil, fmt.Errorf("option %s: %s is out of range %s", p.Name, s, p.Range())
+	}
+	return v, nil
+}
+
+// commonParams are accepted by every player type
+var commonParams = []Param{
+	{Name: "name", Type: StringParam, Description: "name shown when playing, defaults to the type"},
+}
+
+// Options are the validated values of a players options
+type Options map[string]interface{}
+
+// String returns a string option
+func (o Options) String(name string) string {
+	s, _ := o[name].(string)
+	return s
+}
+
+// Int returns an int option
+func (o Options) Int(name string) int {
+	i, _ := o[name].(int)
+	return i
+}
+
+// Float returns a float option
+func (o Options) Float(name string) float64 {
+	f, _ := o[name].(float64)
+	return f
+}
+
+// Bool returns a bool option
+func (o Options) Bool(name string) bool {
+	b, _ := o[name].(bool)
+	return b
+}
+
+// AllParams lists the common and specific options of a player type
+func (t PlayerType) AllParams() []Param {
+	return append(append([]Param{}, commonParams...), t.Params...)
+}
+
+// HasParam reports if the player type accepts an option
+func (t PlayerType) HasParam(name string) bool {
+	for _, p := range t.AllParams() {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse validates a configuration against the options of a player type,
+// applying defaults. Unknown options are rejected.
+func (t PlayerType) Parse(conf map[string]string) (Options, error) {
+	params := t.AllParams()
+	known := make(map[string]Param)
+	for _, p := range params {
+		known[p.Name] = p
+	}
+	for k := range conf {
+		if _, ok := known[k]; !ok && k != "type" {
+			names := make([]string, 0, len(params))
+			for _, p := range params {
+				names = append(names, p.Name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown option %q for player type %s, must be one of: %s",
+				k, t.Name, strings.Join(names, ", "))
+		}
+	}
+
+	opts := Options{"type": t.Name}
+	for _, p := range params {
+		s := conf[p.Name]
+		if s == "" {
+			s = p.defaultValue()
+		}
+		v, err := p.parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("player type %s: %v", t.Name, err)
+		}
+		opts[p.Name] = v
+	}
+	if opts.String("name") == "" {
+		opts["name"] = t.Name
+	}
+	return opts, nil
+}
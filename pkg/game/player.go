@@ -2,9 +2,12 @@ package game
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,92 +20,354 @@ type Player interface {
 }
 
 // RandomPlayer picks a valid random move
-type RandomPlayer struct{}
+type RandomPlayer struct {
+	// Quiet stops the move being shown
+	Quiet bool
+	// rnd is a seeded source, nil using the global source
+	rnd *rand.Rand
+}
 
-func newRandomPlayer(conf map[string]string) (Player, error) {
-	return &RandomPlayer{}, nil
+func newRandomPlayer(opts Options) (Player, error) {
+	p := &RandomPlayer{
+		Quiet: opts.Bool("quiet"),
+	}
+	if seed := opts.Int("seed"); seed != 0 {
+		p.rnd = rand.New(rand.NewSource(int64(seed)))
+	}
+	return p, nil
 }
 
 // Move chooses a valid random move
 func (p *RandomPlayer) Move(pos *Position) (hole int) {
 	moves := pos.ValidMoves()
-	i := rand.Intn(len(moves))
+	var i int
+	if p.rnd != nil {
+		i = p.rnd.Intn(len(moves))
+	} else {
+		i = rand.Intn(len(moves))
+	}
 	hole = moves[i]
-	fmt.Printf("random > %d\n", hole)
+	if !p.Quiet {
+		fmt.Printf("random > %d\n", hole)
+	}
 	return
 }
 
 // ConsolePlayer gets a value from the console
 type ConsolePlayer struct {
 	Name string
+	// In and Out default to the console
+	In  io.Reader
+	Out io.Writer
+	// Coach suggests a move for a ? or hint, nil for none
+	Coach Agent
+	// Warn asks to confirm a move handing over a capture
+	// or giving up a repeat turn that another move avoids
+	Warn bool
+
+	lines chan consoleLine
 }
 
-func newConsolePlayer(conf map[string]string) (Player, error) {
-	return &ConsolePlayer{
-		Name: conf["name"],
-	}, nil
+// consoleLine is a line read from the console
+type consoleLine struct {
+	text string
+	err  error
 }
 
-// Move chooses a valid random move
-func (p *ConsolePlayer) Move(pos *Position) int {
-	fmt.Printf("%s > ", p.Name)
+func newConsolePlayer(opts Options) (Agent, error) {
+	p := &ConsolePlayer{
+		Name: opts.String("name"),
+		In:   os.Stdin,
+		Out:  os.Stdout,
+		Warn: opts.Bool("warn"),
+	}
+	if coach := opts.String("coach"); coach != "" {
+		t, err := LookupPlayerType(coach)
+		if err != nil {
+			return nil, fmt.Errorf("coach: %v", err)
+		}
+		conf := map[string]string{"type": coach}
+		if t.HasParam("depth") {
+			conf["depth"] = strconv.Itoa(opts.Int("strength"))
+		}
+		if t.HasParam("quiet") {
+			conf["quiet"] = "true"
+		}
+		if p.Coach, err = CreateAgent(conf); err != nil {
+			return nil, fmt.Errorf("coach: %v", err)
+		}
+	}
+	return p, nil
+}
+
+// Close stops the coach, if it needs stopping
+func (p *ConsolePlayer) Close() error {
+	if c, ok := p.Coach.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// readLines reads the console until it fails, a single reader
+// keeping any input not yet used for the next move
+func (p *ConsolePlayer) readLines() {
+	reader := bufio.NewReader(p.In)
+	for {
+		x, err := reader.ReadString('\n')
+		p.lines <- consoleLine{text: strings.TrimRight(x, "\r\n"), err: err}
+		if err != nil {
+			close(p.lines)
+			return
+		}
+	}
+}
+
+// Move reads a valid move from the console, resign giving up the game
+// and ? or hint asking the coach
+func (p *ConsolePlayer) Move(ctx context.Context, pos *Position) (int, error) {
+	if p.lines == nil {
+		p.lines = make(chan consoleLine)
+		go p.readLines()
+	}
+	fmt.Fprintf(p.Out, "%s > ", p.Name)
 	moves := pos.ValidMoves()
-	reader := bufio.NewReader(os.Stdin)
+	// unconfirmed is a move waiting on a warning
+	unconfirmed := 0
 	for {
-		x, _ := reader.ReadString('\n')
-		x = strings.TrimRight(x, "\r\n")
-		if hole, err := strconv.Atoi(x); err == nil {
+		var l consoleLine
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case l, ok = <-p.lines:
+			if !ok {
+				return 0, io.EOF
+			}
+		}
+		if unconfirmed != 0 {
+			hole := unconfirmed
+			unconfirmed = 0
+			if l.text == "y" || l.text == "yes" {
+				return hole, nil
+			}
+			if l.err != nil {
+				return 0, l.err
+			}
+			fmt.Fprintf(p.Out, "%s > ", p.Name)
+			continue
+		}
+		if l.text == "resign" {
+			return 0, ErrResign
+		}
+		if l.text == "?" || l.text == "hint" {
+			if p.Coach == nil {
+				fmt.Fprintf(p.Out, " no coach, see the coach option\n%s > ", p.Name)
+				continue
+			}
+			hole, err := p.Coach.Move(ctx, pos)
+			if err != nil {
+				return 0, fmt.Errorf("coach: %v", err)
+			}
+			fmt.Fprintf(p.Out, " hint %d\n%s > ", hole, p.Name)
+			continue
+		}
+		if hole, err := strconv.Atoi(l.text); err == nil {
 			// check value is valid
 			for _, m := range moves {
 				if hole == m {
-					// valid move
-					return hole
+					// valid move, unless it needs confirming
+					if p.Warn {
+						if w := warnings(pos, hole); len(w) > 0 {
+							fmt.Fprintf(p.Out, " %d %s, play it? (y/n) ", hole, strings.Join(w, " and "))
+							unconfirmed = hole
+							break
+						}
+					}
+					return hole, nil
 				}
 			}
-		} else {
-			fmt.Printf(" %s not valid\n---\n", x)
+			if unconfirmed != 0 {
+				continue
+			}
+		}
+		if l.err != nil {
+			return 0, l.err
 		}
+		fmt.Fprintf(p.Out, " %s not valid\n---\n", l.text)
 	}
 }
 
-// PlayerFactory types a function which takes a map and creates a Player
-type PlayerFactory func(conf map[string]string) (Player, error)
+// warnings describes what a move gives away that another move avoids,
+// the largest capture the opponent can reply with and a repeat turn
+func warnings(pos *Position, hole int) []string {
+	moves := pos.ValidMoves()
+	capture := make(map[int]int, len(moves))
+	repeat := make(map[int]bool, len(moves))
+	least, from := -1, make(map[int]int, len(moves))
+	for _, m := range moves {
+		t, err := pos.Play(m)
+		if err != nil {
+			continue
+		}
+		repeat[m] = t.Result == RepeatTurn
+		if t.Result == EndOfTurn {
+			op := t.Next.ChangePlayer()
+			for _, reply := range op.ValidMoves() {
+				if r, err := op.Play(reply); err == nil && r.Steal && r.StealCount > capture[m] {
+					capture[m], from[m] = r.StealCount, reply
+				}
+			}
+		}
+		if least < 0 || capture[m] < least {
+			least = capture[m]
+		}
+	}
+
+	var w []string
+	if capture[hole] > least {
+		w = append(w, fmt.Sprintf("hands over a capture of %d stones by their %d", capture[hole], from[hole]))
+	}
+	if !repeat[hole] {
+		for _, m := range moves {
+			if repeat[m] {
+				w = append(w, fmt.Sprintf("gives up the repeat turn of %d", m))
+				break
+			}
+		}
+	}
+	return w
+}
+
+// PlayerFactory types a function which takes validated options and creates a Player
+type PlayerFactory func(opts Options) (Player, error)
 
-var playerFactories = make(map[string]PlayerFactory)
+// AgentFactory types a function which takes validated options and creates an Agent
+type AgentFactory func(opts Options) (Agent, error)
+
+// PlayerType describes a registered player and its options,
+// created by either a Factory or an Agent factory
+type PlayerType struct {
+	Name        string
+	Description string
+	Params      []Param
+	Factory     PlayerFactory
+	Agent       AgentFactory
+}
 
-// RegisterPlayer registers a name to a PlayerFactory
+var playerTypes = make(map[string]PlayerType)
+
+// RegisterPlayer registers a name to a PlayerFactory with no options
 func RegisterPlayer(name string, factory PlayerFactory) {
-	if factory == nil {
-		log.Panicf("Player factory %s does not exist.", name)
+	RegisterPlayerType(PlayerType{Name: name, Factory: factory})
+}
+
+// RegisterAgent registers a name to an AgentFactory with no options
+func RegisterAgent(name string, factory AgentFactory) {
+	RegisterPlayerType(PlayerType{Name: name, Agent: factory})
+}
+
+// RegisterPlayerType registers a player type with its options
+func RegisterPlayerType(t PlayerType) {
+	if t.Factory == nil && t.Agent == nil {
+		log.Panicf("Player factory %s does not exist.", t.Name)
 	}
-	_, registered := playerFactories[name]
+	_, registered := playerTypes[t.Name]
 	if registered {
-		log.Errorf("Player factory %s already registered. Ignoring.", name)
+		log.Errorf("Player factory %s already registered. Ignoring.", t.Name)
+		return
 	}
-	playerFactories[name] = factory
+	playerTypes[t.Name] = t
 }
 
-// CreatePlayer creates a Player by registered name
-func CreatePlayer(conf map[string]string) (Player, error) {
-	// Query configuration for datastore defaulting to "memory".
-	playerName := conf["type"]
+// PlayerTypes lists the registered player types by name
+func PlayerTypes() []PlayerType {
+	types := make([]PlayerType, 0, len(playerTypes))
+	for _, t := range playerTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
 
-	playerFactory, ok := playerFactories[playerName]
+// LookupPlayerType finds a registered player type
+func LookupPlayerType(name string) (PlayerType, error) {
+	t, ok := playerTypes[name]
 	if !ok {
-		// Factory has not been registered.
-		// Make a list of all available datastore factories for logging.
-		availablePlayers := make([]string, 0, len(playerFactories))
-		for k := range playerFactories {
-			availablePlayers = append(availablePlayers, k)
+		// Player type has not been registered.
+		// Make a list of all available player types for the error.
+		availablePlayers := make([]string, 0, len(playerTypes))
+		for _, t := range PlayerTypes() {
+			availablePlayers = append(availablePlayers, t.Name)
+		}
+		return t, fmt.Errorf("invalid player type %q, must be one of: %s", name, strings.Join(availablePlayers, ", "))
+	}
+	return t, nil
+}
+
+// CreatePlayer creates a Player by registered type,
+// the other values of conf being validated options,
+// adapting player types which only create an Agent
+func CreatePlayer(conf map[string]string) (Player, error) {
+	t, err := LookupPlayerType(conf["type"])
+	if err != nil {
+		return nil, err
+	}
+	opts, err := t.Parse(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Factory == nil {
+		a, err := t.Agent(opts)
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("Invalid Player name. Must be one of: %s", strings.Join(availablePlayers, ", "))
+		return AsPlayer(a), nil
 	}
+	// Run the factory with the options.
+	return t.Factory(opts)
+}
 
-	// Run the factory with the configuration.
-	return playerFactory(conf)
+// CreateAgent creates an Agent by registered type,
+// adapting player types which only create a Player
+func CreateAgent(conf map[string]string) (Agent, error) {
+	t, err := LookupPlayerType(conf["type"])
+	if err != nil {
+		return nil, err
+	}
+	opts, err := t.Parse(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Agent != nil {
+		return t.Agent(opts)
+	}
+	p, err := t.Factory(opts)
+	if err != nil {
+		return nil, err
+	}
+	return AsAgent(p), nil
 }
 
 func init() {
-	RegisterPlayer("random", newRandomPlayer)
-	RegisterPlayer("console", newConsolePlayer)
+	RegisterPlayerType(PlayerType{
+		Name:        "random",
+		Description: "picks a valid random move",
+		Params: []Param{
+			{Name: "quiet", Type: BoolParam, Default: "false", Description: "do not show the move"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "random seed, 0 shares the global source"},
+		},
+		Factory: newRandomPlayer,
+	})
+	RegisterPlayerType(PlayerType{
+		Name:        "console",
+		Description: "enter moves at the console, ? for a hint from the coach, or resign",
+		Params: []Param{
+			{Name: "coach", Type: StringParam, Description: "player type giving hints, none by default"},
+			{Name: "strength", Type: IntParam, Default: "6", Min: 1, Max: 16, Description: "search depth of the coach, when its type has one"},
+			{Name: "warn", Type: BoolParam, Default: "false", Description: "confirm moves handing over a capture or giving up a repeat turn"},
+		},
+		Agent: newConsolePlayer,
+	})
 }
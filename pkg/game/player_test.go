@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePlayer(t *testing.T) {
+	assert := assert.New(t)
+	DefineGame(6, 4)
+
+	p, err := CreatePlayer(map[string]string{"type": "random", "seed": "7", "quiet": "true"})
+	assert.Nil(err)
+	r := p.(*RandomPlayer)
+	assert.True(r.Quiet)
+
+	// a seeded player repeats its moves
+	q, _ := CreatePlayer(map[string]string{"type": "random", "seed": "7", "quiet": "true"})
+	pos := StartPosition()
+	for i := 0; i < 10; i++ {
+		assert.Equal(p.Move(pos), q.Move(pos))
+	}
+
+	_, err = CreatePlayer(map[string]string{"type": "unknown"})
+	assert.EqualError(err, `invalid player type "unknown", must be one of: console, random`)
+
+	_, err = CreatePlayer(map[string]string{"type": "random", "sead": "7"})
+	assert.EqualError(err, `unknown option "sead" for player type random, must be one of: name, quiet, seed`)
+
+	_, err = CreatePlayer(map[string]string{"type": "random", "seed": "x"})
+	assert.EqualError(err, `player type random: option seed: "x" is not an int`)
+}
+
+func TestParseOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	pt := PlayerType{
+		Name: "test",
+		Params: []Param{
+			{Name: "depth", Type: IntParam, Default: "4", Min: 1, Max: 12},
+			{Name: "noise", Type: FloatParam},
+			{Name: "book", Type: StringParam},
+		},
+	}
+	opts, err := pt.Parse(map[string]string{"type": "test", "noise": "0.5"})
+	assert.Nil(err)
+	assert.Equal(4, opts.Int("depth"))
+	assert.Equal(0.5, opts.Float("noise"))
+	assert.Equal("", opts.String("book"))
+	assert.Equal("test", opts.String("name"))
+
+	_, err = pt.Parse(map[string]string{"depth": "13"})
+	assert.EqualError(err, "player type test: option depth: 13 is out of range 1..12")
+}
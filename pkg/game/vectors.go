@@ -0,0 +1,207 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// VectorVersion is the version of the conformance suite layout
+const VectorVersion = 1
+
+// Suite is a language neutral set of conformance vectors.
+// Positions are flattened in AsCsv order, near home and holes
+// followed by far home and holes.
+type Suite struct {
+	Version int       `json:"version"`
+	Rules   Rules     `json:"rules"`
+	Vectors []*Vector `json:"vectors"`
+}
+
+// Vector is the expected outcome of a single move
+type Vector struct {
+	Name       string       `json:"name"`
+	Tags       []string     `json:"tags"`
+	Width      int          `json:"width"`
+	Stones     int          `json:"stones"`
+	Position   []int        `json:"position"`
+	Move       int          `json:"move"`
+	ValidMoves []int        `json:"validMoves"`
+	Next       []int        `json:"next"`
+	Delta      []int        `json:"delta"`
+	Result     MoveResult   `json:"result"`
+	ResultName string       `json:"resultName"`
+	Steal      *VectorSteal `json:"steal"`
+}
+
+// VectorSteal locates the stones captured by a move
+type VectorSteal struct {
+	Row   int `json:"row"`
+	Hole  int `json:"hole"`
+	Count int `json:"count"`
+}
+
+// NewVector plays a move in the current game recording the outcome
+func NewVector(name string, p *Position, hole int) (*Vector, error) {
+	t, err := p.Play(hole)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	v := &Vector{
+		Name:       name,
+		Tags:       make([]string, 0),
+		Width:      WIDTH(),
+		Stones:     STONE(),
+		Position:   flatten(p),
+		Move:       hole,
+		ValidMoves: p.ValidMoves(),
+		Next:       flatten(t.Next),
+		Delta:      flatten(t.Delta),
+		Result:     t.Result,
+		ResultName: t.Result.String(),
+	}
+	if t.Steal {
+		v.Steal = &VectorSteal{Row: t.StealRow, Hole: t.StealHole, Count: t.StealCount}
+	}
+
+	// tag the features exercised
+	stones := p.near().Items[hole]
+	if t.Result == RepeatTurn {
+		v.Tags = append(v.Tags, "repeat")
+	}
+	if stones > hole+WIDTH() {
+		v.Tags = append(v.Tags, "skip-home")
+	}
+	if stones > 2*WIDTH() {
+		v.Tags = append(v.Tags, "lap")
+	}
+	if t.Steal {
+		v.Tags = append(v.Tags, "steal")
+		if t.StealRow == 0 {
+			// last stone landed on the far side
+			v.Tags = append(v.Tags, "steal-far")
+		}
+	}
+	if t.Result == EndOfGame {
+		v.Tags = append(v.Tags, "end")
+	}
+	return v, nil
+}
+
+// flatten a position in AsCsv order
+func flatten(p *Position) []int {
+	vals := make([]int, 0, 2*(WIDTH()+1))
+	for r := range p.Row {
+		vals = append(vals, p.Row[r].Items...)
+	}
+	return vals
+}
+
+// edgeCase is a hand picked position and move
+type edgeCase struct {
+	name   string
+	width  int
+	stones int
+	pos    []int
+	move   int
+}
+
+var edgeCases = []edgeCase{
+	{"start repeat", 6, 4, nil, 4},
+	{"start end of turn", 6, 4, nil, 6},
+	{"skip opponent home", 3, 4, []int{4, 0, 6, 2, 0, 4, 4, 4}, 2},
+	{"lap the board", 3, 4, []int{0, 0, 8, 0, 9, 2, 3, 2}, 2},
+	{"double lap", 3, 4, []int{0, 15, 0, 0, 0, 3, 3, 3}, 1},
+	{"lap repeat", 3, 4, []int{0, 0, 0, 10, 6, 4, 2, 2}, 3},
+	{"steal", 3, 4, []int{0, 0, 1, 3, 8, 4, 4, 4}, 2},
+	{"steal far side", 3, 4, []int{5, 3, 2, 1, 9, 4, 0, 0}, 1},
+	{"lap steal", 3, 4, []int{0, 0, 7, 0, 9, 4, 2, 2}, 2},
+	{"no steal opposite empty", 3, 4, []int{8, 0, 1, 3, 8, 4, 0, 0}, 2},
+	{"end of game", 3, 4, []int{10, 1, 0, 0, 9, 1, 2, 1}, 1},
+	{"end of game steal", 3, 4, []int{10, 0, 1, 0, 9, 1, 2, 1}, 2},
+	{"single hole", 1, 2, []int{0, 2, 0, 2}, 1},
+}
+
+// EdgeCaseVectors creates vectors for hand picked positions,
+// defining each game in turn
+func EdgeCaseVectors() (vectors []*Vector, err error) {
+	for _, c := range edgeCases {
+		DefineGame(c.width, c.stones)
+		p := StartPosition()
+		if c.pos != nil {
+			p = CreatePosition(c.pos...)
+		}
+		if valid, _ := p.IsValid(); !valid {
+			return nil, fmt.Errorf("%s: position has incorrect stones", c.name)
+		}
+		v, err := NewVector(c.name, p, c.move)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, v)
+	}
+	return
+}
+
+// SampleVectors records every move of random games for each
+// width and stones, the seed makes the vectors repeatable
+func SampleVectors(widths []int, stones []int, games int, seed int64) (vectors []*Vector, err error) {
+	rnd := rand.New(rand.NewSource(seed))
+	for _, w := range widths {
+		for _, s := range stones {
+			DefineGame(w, s)
+			for g := 1; g <= games; g++ {
+				p := StartPosition()
+				for ply := 1; ; ply++ {
+					moves := p.ValidMoves()
+					if len(moves) == 0 {
+						break
+					}
+					hole := moves[rnd.Intn(len(moves))]
+					v, err := NewVector(fmt.Sprintf("%dx%d game %d ply %d", w, s, g, ply), p, hole)
+					if err != nil {
+						return nil, err
+					}
+					vectors = append(vectors, v)
+
+					next, _, result, _ := p.Move(hole)
+					if result == EndOfGame {
+						break
+					}
+					if result == EndOfTurn {
+						next = next.ChangePlayer()
+					}
+					p = next
+				}
+			}
+		}
+	}
+	return
+}
+
+// BuildSuite creates the edge cases followed by the sampled vectors,
+// every width and stones being at least 1
+func BuildSuite(widths []int, stones []int, games int, seed int64) (*Suite, error) {
+	for _, w := range widths {
+		if w < 1 {
+			return nil, fmt.Errorf("width %d must be at least 1", w)
+		}
+	}
+	for _, s := range stones {
+		if s < 1 {
+			return nil, fmt.Errorf("stones %d must be at least 1", s)
+		}
+	}
+	vectors, err := EdgeCaseVectors()
+	if err != nil {
+		return nil, err
+	}
+	sampled, err := SampleVectors(widths, stones, games, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Suite{
+		Version: VectorVersion,
+		Rules:   StandardRules,
+		Vectors: append(vectors, sampled...),
+	}, nil
+}
@@ -0,0 +1,137 @@
+package match
+
+import (
+	"math"
+	"sort"
+)
+
+// eloIterations bounds the fitting of ratings
+const eloIterations = 1000
+
+// Outcome is a game between two named players, Score being that of A
+type Outcome struct {
+	A, B  string
+	Score float64
+}
+
+// Rating is the Elo of a player relative to the average of zero,
+// Error being the half width of its 95% confidence interval
+type Rating struct {
+	Name  string  `json:"name"`
+	Elo   float64 `json:"elo"`
+	Error float64 `json:"error"`
+	Games int     `json:"games"`
+	// Score counts a win as 1 and a draw as a half
+	Score float64 `json:"score"`
+}
+
+// Ratings fits Elo ratings to the outcomes by maximum likelihood.
+// Each pair of players is given one virtual draw, so that a player
+// winning or losing every game still has a finite rating.
+// Ratings are ordered best first.
+func Ratings(outcomes []Outcome) []Rating {
+	index := make(map[string]int)
+	var names []string
+	for _, o := range outcomes {
+		for _, name := range []string{o.A, o.B} {
+			if _, ok := index[name]; !ok {
+				index[name] = len(names)
+				names = append(names, name)
+			}
+		}
+	}
+	n := len(names)
+	if n == 0 {
+		return nil
+	}
+
+	// games between each pair and the score of each player
+	games := make([][]float64, n)
+	for i := range games {
+		games[i] = make([]float64, n)
+	}
+	played := make([]int, n)
+	score := make([]float64, n)
+	for _, o := range outcomes {
+		a, b := index[o.A], index[o.B]
+		games[a][b]++
+		games[b][a]++
+		played[a]++
+		played[b]++
+		score[a] += o.Score
+		score[b] += 1 - o.Score
+	}
+	wins := make([]float64, n)
+	for i := range wins {
+		wins[i] = score[i]
+		for j := range wins {
+			if i != j {
+				// the virtual draw
+				games[i][j]++
+				wins[i] += 0.5
+			}
+		}
+	}
+
+	// minorization maximization of the Bradley Terry strengths
+	gamma := make([]float64, n)
+	for i := range gamma {
+		gamma[i] = 1
+	}
+	for it := 0; it < eloIterations; it++ {
+		change := 0.0
+		for i := range gamma {
+			d := 0.0
+			for j := range gamma {
+				if i != j {
+					d += games[i][j] / (gamma[i] + gamma[j])
+				}
+			}
+			if d == 0 {
+				continue
+			}
+			g := wins[i] / d
+			change = math.Max(change, math.Abs(math.Log(g/gamma[i])))
+			gamma[i] = g
+		}
+		if change < 1e-9 {
+			break
+		}
+	}
+
+	// centre on the average
+	elo := make([]float64, n)
+	mean := 0.0
+	for i, g := range gamma {
+		elo[i] = 400 * math.Log10(g)
+		mean += elo[i] / float64(n)
+	}
+	ratings := make([]Rating, n)
+	for i := range ratings {
+		elo[i] -= mean
+	}
+	for i := range ratings {
+		// the fisher information of each rating, the others fixed
+		info := 0.0
+		for j := range ratings {
+			if i != j {
+				e := expected(elo[i] - elo[j])
+				info += games[i][j] * e * (1 - e)
+			}
+		}
+		ratings[i] = Rating{
+			Name:  names[i],
+			Elo:   elo[i],
+			Error: z * 400 / math.Ln10 / math.Sqrt(info),
+			Games: played[i],
+			Score: score[i],
+		}
+	}
+	sort.SliceStable(ratings, func(i, j int) bool { return ratings[i].Elo > ratings[j].Elo })
+	return ratings
+}
+
+// expected is the score expected for an Elo difference
+func expected(diff float64) float64 {
+	return 1 / (1 + math.Pow(10, -diff/400))
+}
@@ -0,0 +1,244 @@
+// Package match plays matches and tournaments between player types
+package match
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// z is the normal quantile for 95% confidence intervals
+const z = 1.96
+
+// Config describes a match, the player configurations
+// being those given to game.CreateAgent
+type Config struct {
+	P1, P2 map[string]string
+	Games  int
+	// Seed derives the seed of any player type accepting one
+	Seed int64
+	// Clock is the time control of each game, zero for none
+	Clock game.TimeControl
+}
+
+// Game is the outcome of a single game
+type Game struct {
+	// First is the player moving first, 0 for p1 and 1 for p2
+	First  int
+	Result *game.GameResult
+	// Moves are the holes played in turn
+	Moves []int
+}
+
+// play a game from the start, agents[first] moving first,
+// on the clock unless the time control is zero
+func play(ctx context.Context, agents [2]game.Agent, first int, tc game.TimeControl) (Game, error) {
+	g := Game{First: first}
+	order := [2]game.Agent{agents[first], agents[1-first]}
+	var clocks *game.Clocks
+	if tc != (game.TimeControl{}) {
+		clocks = game.NewClocks(tc)
+	}
+	result, err := game.PlayTimedGame(ctx, order, game.StartPosition(), clocks,
+		func(side int, pos *game.Position, hole int, t *game.Transition) {
+			g.Moves = append(g.Moves, hole)
+		})
+	g.Result = result
+	return g, err
+}
+
+// p1Score is 1 for a p1 win, 0.5 for a draw
+func (g Game) p1Score() float64 {
+	switch g.Result.Winner {
+	case -1:
+		return 0.5
+	case g.First:
+		return 1
+	}
+	return 0
+}
+
+// firstScore is 1 for a win by the first player, 0.5 for a draw
+func (g Game) firstScore() float64 {
+	switch g.Result.Winner {
+	case -1:
+		return 0.5
+	case 0:
+		return 1
+	}
+	return 0
+}
+
+// p1Margin is the p1 store less the p2 store
+func (g Game) p1Margin() float64 {
+	if g.First == 0 {
+		return float64(g.Result.Score)
+	}
+	return float64(-g.Result.Score)
+}
+
+// seeded sets a seed derived from the match seed,
+// unless the configuration already has one
+func seeded(conf map[string]string, seed int64) (map[string]string, error) {
+	t, err := game.LookupPlayerType(conf["type"])
+	if err != nil {
+		return nil, err
+	}
+	c := make(map[string]string, len(conf)+2)
+	for k, v := range conf {
+		c[k] = v
+	}
+	if t.HasParam("seed") && c["seed"] == "" {
+		c["seed"] = strconv.FormatInt(seed, 10)
+	}
+	if t.HasParam("quiet") {
+		c["quiet"] = "true"
+	}
+	return c, nil
+}
+
+// createAgent creates a quiet agent, seeded if the player type accepts one
+func createAgent(conf map[string]string, seed int64) (game.Agent, error) {
+	c, err := seeded(conf, seed)
+	if err != nil {
+		return nil, err
+	}
+	return game.CreateAgent(c)
+}
+
+// closeAgent releases an agent holding resources
+func closeAgent(a game.Agent) {
+	if c, ok := a.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// Run plays the games alternating who moves first,
+// calling progress, if not nil, after each game
+func Run(ctx context.Context, conf Config, progress func(g Game)) ([]Game, error) {
+	var agents [2]game.Agent
+	for i, pc := range []map[string]string{conf.P1, conf.P2} {
+		a, err := createAgent(pc, conf.Seed*2+int64(i)+1)
+		if err != nil {
+			return nil, fmt.Errorf("p%d: %v", i+1, err)
+		}
+		defer closeAgent(a)
+		agents[i] = a
+	}
+
+	games := make([]Game, 0, conf.Games)
+	for i := 0; i < conf.Games; i++ {
+		g, err := play(ctx, agents, i%2, conf.Clock)
+		if err != nil {
+			return games, fmt.Errorf("game %d: %v", i+1, err)
+		}
+		games = append(games, g)
+		if progress != nil {
+			progress(g)
+		}
+	}
+	return games, nil
+}
+
+// Estimate is a mean with its 95% confidence interval
+type Estimate struct {
+	Mean float64 `json:"mean"`
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// estimate the mean of samples using the normal approximation
+func estimate(vals []float64) Estimate {
+	n := float64(len(vals))
+	if n == 0 {
+		return Estimate{}
+	}
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	mean := sum / n
+	ss := 0.0
+	for _, v := range vals {
+		ss += (v - mean) * (v - mean)
+	}
+	half := 0.0
+	if n > 1 {
+		half = z * math.Sqrt(ss/(n-1)/n)
+	}
+	return Estimate{Mean: mean, Low: mean - half, High: mean + half}
+}
+
+// Summary are the statistics of a match from the side of p1
+type Summary struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+	// Score counts a win as 1 and a draw as a half
+	Score Estimate `json:"score"`
+	// Margin is the p1 store less the p2 store
+	Margin Estimate `json:"margin"`
+	// Length is the number of moves in a game
+	Length Estimate `json:"length"`
+	// First is the score of the player moving first
+	First Estimate `json:"firstPlayerScore"`
+	// Resigned counts the games ending by resignation
+	Resigned int `json:"resigned"`
+	// TimeOuts counts the games lost on time
+	TimeOuts int `json:"timeOuts"`
+}
+
+// Summarise the games of a match
+func Summarise(games []Game) *Summary {
+	s := &Summary{Games: len(games)}
+	var score, margin, length, first []float64
+	for _, g := range games {
+		p1 := g.p1Score()
+		switch p1 {
+		case 1:
+			s.Wins++
+		case 0:
+			s.Losses++
+		default:
+			s.Draws++
+		}
+		if g.Result.Resigned {
+			s.Resigned++
+		}
+		if g.Result.TimeOut {
+			s.TimeOuts++
+		}
+		score = append(score, p1)
+		margin = append(margin, g.p1Margin())
+		length = append(length, float64(g.Result.Plies))
+		first = append(first, g.firstScore())
+	}
+	s.Score = estimate(score)
+	s.Margin = estimate(margin)
+	s.Length = estimate(length)
+	s.First = estimate(first)
+	return s
+}
+
+// Write the summary as text, names labelling p1 and p2
+func (s *Summary) Write(w io.Writer, p1 string, p2 string) {
+	fmt.Fprintf(w, "games         %d, %s vs %s\n", s.Games, p1, p2)
+	fmt.Fprintf(w, "%-13s wins %d draws %d losses %d", p1, s.Wins, s.Draws, s.Losses)
+	if s.Resigned > 0 {
+		fmt.Fprintf(w, " (%d resigned)", s.Resigned)
+	}
+	if s.TimeOuts > 0 {
+		fmt.Fprintf(w, " (%d on time)", s.TimeOuts)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "95%% confidence intervals\n")
+	fmt.Fprintf(w, "score         %.3f [%.3f, %.3f]\n", s.Score.Mean, s.Score.Low, s.Score.High)
+	fmt.Fprintf(w, "store margin  %+.2f [%+.2f, %+.2f]\n", s.Margin.Mean, s.Margin.Low, s.Margin.High)
+	fmt.Fprintf(w, "game length   %.1f [%.1f, %.1f] moves\n", s.Length.Mean, s.Length.Low, s.Length.High)
+	fmt.Fprintf(w, "first player  %.3f [%.3f, %.3f]\n", s.First.Mean, s.First.Low, s.First.High)
+}
@@ -0,0 +1,55 @@
+package match
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	_ "github.com/EFX-PXT1/mancala-go/pkg/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	conf := Config{
+		P1:    map[string]string{"type": "random"},
+		P2:    map[string]string{"type": "random"},
+		Games: 50,
+		Seed:  3,
+	}
+	games, err := Run(context.Background(), conf, nil)
+	assert.Nil(err)
+	assert.Len(games, 50)
+	s := Summarise(games)
+	assert.Equal(50, s.Wins+s.Draws+s.Losses)
+	assert.True(s.Score.Low <= s.Score.Mean && s.Score.Mean <= s.Score.High)
+
+	// a fixed seed repeats the match
+	again, _ := Run(context.Background(), conf, nil)
+	assert.Equal(s, Summarise(again))
+
+	// the players alternate moving first
+	assert.Equal(0, games[0].First)
+	assert.Equal(1, games[1].First)
+
+	conf.P1 = map[string]string{"type": "minimax", "depth": "4"}
+	games, err = Run(context.Background(), conf, nil)
+	assert.Nil(err)
+	assert.True(Summarise(games).Wins > 40)
+
+	conf.P2 = map[string]string{"type": "unknown"}
+	_, err = Run(context.Background(), conf, nil)
+	assert.EqualError(err, `p2: invalid player type "unknown", must be one of: console, minimax, random`)
+}
+
+func TestEstimate(t *testing.T) {
+	assert := assert.New(t)
+
+	e := estimate([]float64{1, 0, 1, 0})
+	assert.Equal(0.5, e.Mean)
+	assert.InDelta(0.5-1.96*0.5774/2, e.Low, 1e-3)
+	assert.InDelta(0.5+1.96*0.5774/2, e.High, 1e-3)
+	assert.Equal(Estimate{Mean: 2, Low: 2, High: 2}, estimate([]float64{2}))
+}
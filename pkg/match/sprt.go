@@ -0,0 +1,208 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Decision is the state of a sequential probability ratio test
+type Decision int
+
+const (
+	// Continue until a bound is crossed
+	Continue Decision = iota
+	// AcceptH0 as the candidate is no stronger than elo0
+	AcceptH0
+	// AcceptH1 as the candidate is stronger by at least elo1
+	AcceptH1
+	// Inconclusive when the games ran out first
+	Inconclusive
+)
+
+// String describes a Decision
+func (d Decision) String() string {
+	switch d {
+	case AcceptH0:
+		return "H0 accepted"
+	case AcceptH1:
+		return "H1 accepted"
+	case Inconclusive:
+		return "inconclusive"
+	}
+	return "continue"
+}
+
+// SPRTConfig describes a test of a candidate against a baseline
+type SPRTConfig struct {
+	Baseline, Candidate map[string]string
+	// Elo0 and Elo1 are the Elo differences of the two hypotheses
+	Elo0, Elo1 float64
+	// Alpha and Beta are the false positive and false negative rates
+	Alpha, Beta float64
+	// OpeningPlies are random moves played before each pair of games
+	OpeningPlies int
+	// MaxGames stops an inconclusive test, zero for no limit
+	MaxGames int
+	// Seed derives the openings and the seed of any player type accepting one
+	Seed int64
+}
+
+// SPRTState is the progress of a test from the side of the candidate
+type SPRTState struct {
+	Games  int
+	Wins   int
+	Draws  int
+	Losses int
+	// Elo is estimated from the pairs played
+	Elo Estimate
+	// LLR is the log likelihood ratio, Lower and Upper its bounds
+	LLR          float64
+	Lower, Upper float64
+	Decision     Decision
+
+	pairs []float64
+}
+
+// Bounds are the log likelihood ratios accepting H0 and H1
+func Bounds(alpha float64, beta float64) (float64, float64) {
+	return math.Log(beta / (1 - alpha)), math.Log((1 - beta) / alpha)
+}
+
+// LLR is the generalised log likelihood ratio of the scores of
+// paired games, each the average score of a pair, using the
+// normal approximation of the pentanomial model. The variance has
+// a prior of a won and a lost pair, so identical pairs such as
+// a sweep still give evidence.
+func LLR(pairs []float64, elo0 float64, elo1 float64) float64 {
+	n := float64(len(pairs))
+	if n < 2 {
+		return 0
+	}
+	sum := 0.0
+	for _, p := range pairs {
+		sum += p
+	}
+	mean := sum / n
+	v := mean*mean + (1-mean)*(1-mean)
+	for _, p := range pairs {
+		v += (p - mean) * (p - mean)
+	}
+	v /= n + 2
+	s0, s1 := expected(elo0), expected(elo1)
+	return n * (s1 - s0) * (2*mean - s0 - s1) / (2 * v)
+}
+
+// eloEstimate converts the scores of pairs into an Elo difference
+func eloEstimate(pairs []float64) Estimate {
+	e := estimate(pairs)
+	return Estimate{Mean: eloOf(e.Mean), Low: eloOf(e.Low), High: eloOf(e.High)}
+}
+
+// eloOf is the Elo difference expected to give a score
+func eloOf(score float64) float64 {
+	score = math.Min(math.Max(score, 1e-6), 1-1e-6)
+	return 400 * math.Log10(score/(1-score))
+}
+
+// add the two games of a pair, scores being those of the candidate
+func (s *SPRTState) add(conf SPRTConfig, scores ...float64) {
+	pair := 0.0
+	for _, score := range scores {
+		s.Games++
+		switch score {
+		case 1:
+			s.Wins++
+		case 0:
+			s.Losses++
+		default:
+			s.Draws++
+		}
+		pair += score / float64(len(scores))
+	}
+	s.pairs = append(s.pairs, pair)
+	s.Elo = eloEstimate(s.pairs)
+	s.LLR = LLR(s.pairs, conf.Elo0, conf.Elo1)
+	switch {
+	case s.LLR >= s.Upper:
+		s.Decision = AcceptH1
+	case s.LLR <= s.Lower:
+		s.Decision = AcceptH0
+	case conf.MaxGames > 0 && s.Games >= conf.MaxGames:
+		s.Decision = Inconclusive
+	}
+}
+
+// openingTries limits the random openings tried before giving up
+const openingTries = 1000
+
+// Opening plays random moves from the start, the game not yet over,
+// failing when no opening tried leaves the game unfinished
+func Opening(rnd *rand.Rand, plies int) (*game.Position, error) {
+	for try := 0; try < openingTries; try++ {
+		pos := game.StartPosition()
+		for i := 0; i < plies && !pos.IsGameEnd(); i++ {
+			moves := pos.ValidMoves()
+			t, _ := pos.Play(moves[rnd.Intn(len(moves))])
+			pos = t.Next
+			if t.Result == game.EndOfTurn {
+				pos = pos.ChangePlayer()
+			}
+		}
+		if !pos.IsGameEnd() {
+			return pos, nil
+		}
+	}
+	return nil, fmt.Errorf("no opening of %d plies in %d tries leaves the game unfinished", plies, openingTries)
+}
+
+// RunSPRT plays pairs of games from random openings, each player
+// moving first from the opening once, until the test decides.
+// progress, if not nil, is called after each pair.
+func RunSPRT(ctx context.Context, conf SPRTConfig, progress func(s *SPRTState)) (*SPRTState, error) {
+	if conf.Elo1 <= conf.Elo0 {
+		return nil, fmt.Errorf("elo1 %g must be greater than elo0 %g", conf.Elo1, conf.Elo0)
+	}
+	if conf.Alpha <= 0 || conf.Alpha >= 1 || conf.Beta <= 0 || conf.Beta >= 1 {
+		return nil, fmt.Errorf("alpha and beta must be between 0 and 1")
+	}
+
+	// the candidate is agents[0]
+	var agents [2]game.Agent
+	for i, pc := range []map[string]string{conf.Candidate, conf.Baseline} {
+		a, err := createAgent(pc, conf.Seed*2+int64(i)+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", []string{"candidate", "baseline"}[i], err)
+		}
+		defer closeAgent(a)
+		agents[i] = a
+	}
+
+	s := &SPRTState{}
+	s.Lower, s.Upper = Bounds(conf.Alpha, conf.Beta)
+	rnd := rand.New(rand.NewSource(conf.Seed))
+	for s.Decision == Continue {
+		start, err := Opening(rnd, conf.OpeningPlies)
+		if err != nil {
+			return s, err
+		}
+		var scores [2]float64
+		for first := range scores {
+			order := [2]game.Agent{agents[first], agents[1-first]}
+			result, err := game.PlayGame(ctx, order, start, nil)
+			if err != nil {
+				return s, fmt.Errorf("game %d: %v", s.Games+first+1, err)
+			}
+			g := Game{First: first, Result: result}
+			scores[first] = g.p1Score()
+		}
+		s.add(conf, scores[:]...)
+		if progress != nil {
+			progress(s)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,103 @@
+package match
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLLR(t *testing.T) {
+	assert := assert.New(t)
+
+	lower, upper := Bounds(0.05, 0.05)
+	assert.InDelta(-2.944, lower, 1e-3)
+	assert.InDelta(2.944, upper, 1e-3)
+
+	// scores above the midpoint of the hypotheses favour H1
+	strong := []float64{1, 0.5, 1, 0.5, 1, 0.5}
+	weak := []float64{0, 0.5, 0, 0.5, 0, 0.5}
+	assert.True(LLR(strong, 0, 20) > 0)
+	assert.True(LLR(weak, 0, 20) < 0)
+	// drawn pairs fall short of the midpoint of the hypotheses
+	assert.True(LLR([]float64{0.5, 0.5, 0.5}, 0, 20) < 0)
+	// evidence grows with the pairs, twice as fast once the prior is outweighed
+	assert.True(LLR(append(strong, strong...), 0, 20) > 2*LLR(strong, 0, 20))
+	many := make([]float64, 0, 6000)
+	for i := 0; i < 1000; i++ {
+		many = append(many, strong...)
+	}
+	assert.InDelta(2, LLR(append(many, many...), 0, 20)/LLR(many, 0, 20), 1e-2)
+}
+
+func TestSPRTSweep(t *testing.T) {
+	assert := assert.New(t)
+
+	// a side winning every pair is decided without a game limit
+	for _, c := range []struct {
+		pair     float64
+		decision Decision
+	}{{1, AcceptH1}, {0, AcceptH0}} {
+		conf := SPRTConfig{Elo0: 0, Elo1: 20, Alpha: 0.05, Beta: 0.05}
+		s := &SPRTState{}
+		s.Lower, s.Upper = Bounds(conf.Alpha, conf.Beta)
+		for s.Decision == Continue && s.Games < 1000 {
+			s.add(conf, c.pair, c.pair)
+		}
+		assert.Equal(c.decision, s.Decision)
+		assert.True(s.Games < 100)
+	}
+}
+
+func TestRunSPRT(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	conf := SPRTConfig{
+		Candidate:    map[string]string{"type": "minimax", "depth": "4"},
+		Baseline:     map[string]string{"type": "random"},
+		Elo0:         0,
+		Elo1:         20,
+		Alpha:        0.05,
+		Beta:         0.05,
+		OpeningPlies: 2,
+		Seed:         1,
+	}
+	reports := 0
+	s, err := RunSPRT(context.Background(), conf, func(s *SPRTState) { reports++ })
+	assert.Nil(err)
+	assert.Equal(AcceptH1, s.Decision)
+	assert.Equal(s.Games, 2*reports)
+	assert.True(s.LLR >= s.Upper)
+	assert.True(s.Elo.Mean > 0)
+
+	// the weaker candidate is rejected
+	conf.Candidate, conf.Baseline = conf.Baseline, conf.Candidate
+	s, err = RunSPRT(context.Background(), conf, nil)
+	assert.Nil(err)
+	assert.Equal(AcceptH0, s.Decision)
+
+	conf.Elo1 = 0
+	_, err = RunSPRT(context.Background(), conf, nil)
+	assert.EqualError(err, "elo1 0 must be greater than elo0 0")
+}
+
+func TestOpening(t *testing.T) {
+	assert := assert.New(t)
+	rnd := rand.New(rand.NewSource(1))
+
+	game.DefineGame(4, 3)
+	pos, err := Opening(rnd, 4)
+	assert.Nil(err)
+	assert.False(pos.IsGameEnd())
+
+	// every opening ends the game
+	game.DefineGame(2, 1)
+	_, err = Opening(rnd, 100)
+	assert.EqualError(err, "no opening of 100 plies in 1000 tries leaves the game unfinished")
+	game.DefineGame(4, 0)
+	_, err = Opening(rnd, 0)
+	assert.NotNil(err)
+}
@@ -0,0 +1,132 @@
+package match
+
+import (
+	"sort"
+	"time"
+
+	bh "github.com/timshannon/badgerhold/v2"
+)
+
+// TournamentRecord is a stored tournament
+type TournamentRecord struct {
+	ID      uint64 `badgerhold:"key"`
+	Format  Format
+	Width   int
+	Stones  int
+	Players []string
+	Started time.Time
+}
+
+// PlayerRecord is the latest configuration of a named player
+type PlayerRecord struct {
+	Name string `badgerhold:"key"`
+	Conf map[string]string
+}
+
+// GameRecord is a stored game between two named players
+type GameRecord struct {
+	ID         uint64 `badgerhold:"key"`
+	Tournament uint64 `badgerholdIndex:"Tournament"`
+	Round      int
+	// First moved first against Second
+	First  string `badgerholdIndex:"First"`
+	Second string `badgerholdIndex:"Second"`
+	Width  int
+	Stones int
+	Moves  []int
+	// Winner is 0 when First won, 1 when Second won and -1 for a draw
+	Winner   int
+	Score    int
+	Plies    int
+	Resigned bool
+	Played   time.Time
+}
+
+// Outcome is the game from the side of the first player
+func (g *GameRecord) Outcome() Outcome {
+	o := Outcome{A: g.First, B: g.Second, Score: 0.5}
+	switch g.Winner {
+	case 0:
+		o.Score = 1
+	case 1:
+		o.Score = 0
+	}
+	return o
+}
+
+// Store is a badgerhold database of tournaments, so
+// ratings accumulate over many events
+type Store struct {
+	store *bh.Store
+}
+
+// OpenStore opens or creates the store in dir
+func OpenStore(dir string) (*Store, error) {
+	options := bh.DefaultOptions
+	options.Dir = dir
+	options.ValueDir = dir
+	options.Logger = nil
+	store, err := bh.Open(options)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{store: store}, nil
+}
+
+// AddTournament stores a tournament, setting its ID
+func (s *Store) AddTournament(t *TournamentRecord) error {
+	return s.store.Insert(bh.NextSequence(), t)
+}
+
+// AddPlayer stores the configuration of a player
+func (s *Store) AddPlayer(p *PlayerRecord) error {
+	return s.store.Upsert(p.Name, p)
+}
+
+// AddGame stores a game, setting its ID
+func (s *Store) AddGame(g *GameRecord) error {
+	return s.store.Insert(bh.NextSequence(), g)
+}
+
+// Player finds the configuration of a player
+func (s *Store) Player(name string) (*PlayerRecord, error) {
+	p := &PlayerRecord{}
+	if err := s.store.Get(name, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Games finds the games of a width and stones in the order played,
+// only those of a player unless empty
+func (s *Store) Games(width int, stones int, player string) ([]*GameRecord, error) {
+	q := bh.Where("Width").Eq(width).And("Stones").Eq(stones)
+	if player != "" {
+		q = q.And("First").Eq(player).Or(
+			bh.Where("Width").Eq(width).And("Stones").Eq(stones).And("Second").Eq(player))
+	}
+	var games []*GameRecord
+	if err := s.store.Find(&games, q); err != nil {
+		return nil, err
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+	return games, nil
+}
+
+// Ratings fits the ratings of every game of a width and stones
+func (s *Store) Ratings(width int, stones int) ([]Rating, error) {
+	games, err := s.Games(width, stones, "")
+	if err != nil {
+		return nil, err
+	}
+	outcomes := make([]Outcome, len(games))
+	for i, g := range games {
+		outcomes[i] = g.Outcome()
+	}
+	return Ratings(outcomes), nil
+}
+
+// Close closes the underlying store
+func (s *Store) Close() error {
+	return s.store.Close()
+}
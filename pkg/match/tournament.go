@@ -0,0 +1,265 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Format is the pairing system of a tournament
+type Format string
+
+const (
+	// RoundRobin pairs every player with every other
+	RoundRobin Format = "roundrobin"
+	// Swiss pairs players on similar points each round
+	Swiss Format = "swiss"
+)
+
+// ParseFormat converts a name into a Format
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case RoundRobin, Swiss:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid tournament format %q, must be one of: roundrobin, swiss", s)
+}
+
+// Entrant is a named player configuration
+type Entrant struct {
+	Name string
+	Conf map[string]string
+}
+
+// TournamentConfig describes a tournament
+type TournamentConfig struct {
+	Entrants []Entrant
+	Format   Format
+	// Rounds of a Swiss tournament, zero for enough to find a winner
+	Rounds int
+	// Games each pairing plays, alternating who moves first
+	Games int
+	// Seed derives the seed of any player type accepting one
+	Seed int64
+	// Clock is the time control of each game, zero for none
+	Clock game.TimeControl
+}
+
+// Standing is the points of a player in a tournament,
+// a win counting 1, a draw a half and a bye as drawn games
+type Standing struct {
+	Name   string
+	Points float64
+	Games  int
+	Byes   int
+}
+
+// pairing is two entrants to play, b being -1 for a bye
+type pairing struct {
+	a, b int
+}
+
+// tournament is the state of a tournament being played
+type tournament struct {
+	conf      TournamentConfig
+	agents    []game.Agent
+	standings []Standing
+	met       map[pairing]bool
+}
+
+// roundRobin pairs everyone by the circle method,
+// each player meeting one other in each round
+func roundRobin(n int) [][]pairing {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i
+	}
+	if n%2 == 1 {
+		ids = append(ids, -1)
+	}
+	m := len(ids)
+	var rounds [][]pairing
+	for r := 0; r < m-1; r++ {
+		var round []pairing
+		for i := 0; i < m/2; i++ {
+			a, b := ids[i], ids[m-1-i]
+			if a == -1 {
+				a, b = b, a
+			}
+			round = append(round, pairing{a, b})
+		}
+		rounds = append(rounds, round)
+		// keep the first fixed, rotating the rest
+		ids = append([]int{ids[0], ids[m-1]}, ids[1:m-1]...)
+	}
+	return rounds
+}
+
+// swissRound pairs players in order of points, avoiding rematches
+// where possible, the lowest placed without one taking any bye
+func (t *tournament) swissRound() []pairing {
+	order := make([]int, len(t.standings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return t.standings[order[i]].Points > t.standings[order[j]].Points
+	})
+
+	var round []pairing
+	if len(order)%2 == 1 {
+		bye := len(order) - 1
+		for i := len(order) - 1; i >= 0; i-- {
+			if t.standings[order[i]].Byes == 0 {
+				bye = i
+				break
+			}
+		}
+		round = append(round, pairing{order[bye], -1})
+		order = append(order[:bye], order[bye+1:]...)
+	}
+	for len(order) > 0 {
+		a, opp := order[0], 1
+		for i := 1; i < len(order); i++ {
+			if !t.met[pairing{a, order[i]}] {
+				opp = i
+				break
+			}
+		}
+		round = append(round, pairing{a, order[opp]})
+		order = append(order[1:opp], order[opp+1:]...)
+	}
+	return round
+}
+
+// swissRounds is enough rounds to find a winner
+func swissRounds(n int) int {
+	return int(math.Ceil(math.Log2(float64(n))))
+}
+
+// RunTournament plays every round, storing the games if store
+// is not nil and calling progress, if not nil, after each game
+func RunTournament(ctx context.Context, conf TournamentConfig, store *Store,
+	progress func(g *GameRecord)) ([]*GameRecord, []Standing, error) {
+	n := len(conf.Entrants)
+	if n < 2 {
+		return nil, nil, fmt.Errorf("tournament needs at least 2 players, found %d", n)
+	}
+	t := &tournament{
+		conf:      conf,
+		standings: make([]Standing, n),
+		met:       make(map[pairing]bool),
+	}
+	names := make([]string, n)
+	for i, e := range conf.Entrants {
+		for _, other := range names[:i] {
+			if other == e.Name {
+				return nil, nil, fmt.Errorf("player %s entered twice", e.Name)
+			}
+		}
+		names[i] = e.Name
+		t.standings[i].Name = e.Name
+		a, err := createAgent(e.Conf, conf.Seed*int64(n)+int64(i)+1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("player %s: %v", e.Name, err)
+		}
+		defer closeAgent(a)
+		t.agents = append(t.agents, a)
+	}
+
+	record := &TournamentRecord{
+		Format:  conf.Format,
+		Width:   game.WIDTH(),
+		Stones:  game.STONE(),
+		Players: names,
+		Started: time.Now().UTC(),
+	}
+	if store != nil {
+		if err := store.AddTournament(record); err != nil {
+			return nil, nil, err
+		}
+		for _, e := range conf.Entrants {
+			if err := store.AddPlayer(&PlayerRecord{Name: e.Name, Conf: e.Conf}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var schedule [][]pairing
+	rounds := conf.Rounds
+	if conf.Format == RoundRobin {
+		schedule = roundRobin(n)
+		rounds = len(schedule)
+	} else if rounds == 0 {
+		rounds = swissRounds(n)
+	}
+
+	var games []*GameRecord
+	for r := 0; r < rounds; r++ {
+		var round []pairing
+		if conf.Format == RoundRobin {
+			round = schedule[r]
+		} else {
+			round = t.swissRound()
+		}
+		for _, p := range round {
+			if p.b == -1 {
+				t.standings[p.a].Byes++
+				t.standings[p.a].Points += float64(conf.Games) / 2
+				continue
+			}
+			t.met[p] = true
+			t.met[pairing{p.b, p.a}] = true
+			for i := 0; i < conf.Games; i++ {
+				g, err := play(ctx, [2]game.Agent{t.agents[p.a], t.agents[p.b]}, i%2, t.conf.Clock)
+				if err != nil {
+					return games, t.sorted(), fmt.Errorf("round %d, %s vs %s: %v", r+1, names[p.a], names[p.b], err)
+				}
+				first, second := p.a, p.b
+				if g.First == 1 {
+					first, second = p.b, p.a
+				}
+				rec := &GameRecord{
+					Tournament: record.ID,
+					Round:      r + 1,
+					First:      names[first],
+					Second:     names[second],
+					Width:      game.WIDTH(),
+					Stones:     game.STONE(),
+					Moves:      g.Moves,
+					Winner:     g.Result.Winner,
+					Score:      g.Result.Score,
+					Plies:      g.Result.Plies,
+					Resigned:   g.Result.Resigned,
+					Played:     time.Now().UTC(),
+				}
+				o := rec.Outcome()
+				t.standings[first].Points += o.Score
+				t.standings[second].Points += 1 - o.Score
+				t.standings[first].Games++
+				t.standings[second].Games++
+				if store != nil {
+					if err := store.AddGame(rec); err != nil {
+						return games, t.sorted(), err
+					}
+				}
+				games = append(games, rec)
+				if progress != nil {
+					progress(rec)
+				}
+			}
+		}
+	}
+	return games, t.sorted(), nil
+}
+
+// sorted standings by points, keeping the entry order on a tie
+func (t *tournament) sorted() []Standing {
+	s := append([]Standing{}, t.standings...)
+	sort.SliceStable(s, func(i, j int) bool { return s[i].Points > s[j].Points })
+	return s
+}
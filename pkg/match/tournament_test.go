@@ -0,0 +1,126 @@
+package match
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundRobin(t *testing.T) {
+	assert := assert.New(t)
+
+	for n := 2; n <= 7; n++ {
+		met := make(map[pairing]int)
+		rounds := roundRobin(n)
+		for _, round := range rounds {
+			seen := make(map[int]bool)
+			for _, p := range round {
+				assert.False(seen[p.a] || seen[p.b], "n %d plays twice in a round", n)
+				seen[p.a], seen[p.b] = true, true
+				if p.b != -1 {
+					if p.a > p.b {
+						p.a, p.b = p.b, p.a
+					}
+					met[p]++
+				}
+			}
+		}
+		// every pair meets once
+		assert.Len(met, n*(n-1)/2)
+		for _, c := range met {
+			assert.Equal(1, c)
+		}
+	}
+}
+
+func TestSwissRound(t *testing.T) {
+	assert := assert.New(t)
+
+	tr := &tournament{
+		standings: []Standing{{Points: 2}, {Points: 2}, {Points: 1}, {Points: 0}, {Points: 0, Byes: 1}},
+		met:       map[pairing]bool{{0, 1}: true, {1, 0}: true},
+	}
+	// the lowest without a bye takes it, leaders avoid a rematch
+	assert.Equal([]pairing{{3, -1}, {0, 2}, {1, 4}}, tr.swissRound())
+}
+
+func TestRatings(t *testing.T) {
+	assert := assert.New(t)
+
+	var outcomes []Outcome
+	for i := 0; i < 30; i++ {
+		outcomes = append(outcomes, Outcome{A: "strong", B: "weak", Score: 1})
+		outcomes = append(outcomes, Outcome{A: "weak", B: "strong", Score: 0.5})
+		outcomes = append(outcomes, Outcome{A: "even", B: "strong", Score: 0.5})
+		outcomes = append(outcomes, Outcome{A: "even", B: "weak", Score: 0.5})
+	}
+	ratings := Ratings(outcomes)
+	assert.Equal("strong", ratings[0].Name)
+	assert.Equal("weak", ratings[2].Name)
+	assert.InDelta(0, ratings[0].Elo+ratings[1].Elo+ratings[2].Elo, 1e-6)
+	assert.Equal(90, ratings[0].Games)
+	assert.Equal(60.0, ratings[0].Score)
+	for _, r := range ratings {
+		assert.True(r.Error > 0)
+	}
+
+	// winning every game is still finite
+	ratings = Ratings([]Outcome{{A: "a", B: "b", Score: 1}})
+	assert.InDelta(-ratings[1].Elo, ratings[0].Elo, 1e-6)
+	assert.True(ratings[0].Elo > 0 && ratings[0].Elo < 1000)
+}
+
+func TestRunTournament(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	dir, err := ioutil.TempDir("", "tournament-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	store, err := OpenStore(dir)
+	assert.Nil(err)
+	defer store.Close()
+
+	conf := TournamentConfig{
+		Entrants: []Entrant{
+			{Name: "deep", Conf: map[string]string{"type": "minimax", "depth": "4"}},
+			{Name: "rnd", Conf: map[string]string{"type": "random"}},
+			{Name: "rnd2", Conf: map[string]string{"type": "random"}},
+		},
+		Format: RoundRobin,
+		Games:  4,
+		Seed:   1,
+	}
+	games, standings, err := RunTournament(context.Background(), conf, store, nil)
+	assert.Nil(err)
+	assert.Len(games, 12)
+	assert.Equal("deep", standings[0].Name)
+	assert.Equal(8, standings[0].Games)
+
+	// a second tournament accumulates
+	conf.Format = Swiss
+	_, _, err = RunTournament(context.Background(), conf, store, nil)
+	assert.Nil(err)
+	stored, err := store.Games(4, 3, "")
+	assert.Nil(err)
+	assert.True(len(stored) > 12)
+	mine, err := store.Games(4, 3, "rnd2")
+	assert.Nil(err)
+	for _, g := range mine {
+		assert.True(g.First == "rnd2" || g.Second == "rnd2")
+	}
+	ratings, err := store.Ratings(4, 3)
+	assert.Nil(err)
+	assert.Equal("deep", ratings[0].Name)
+	p, err := store.Player("deep")
+	assert.Nil(err)
+	assert.Equal("4", p.Conf["depth"])
+
+	conf.Entrants = conf.Entrants[:1]
+	_, _, err = RunTournament(context.Background(), conf, nil, nil)
+	assert.EqualError(err, "tournament needs at least 2 players, found 1")
+}
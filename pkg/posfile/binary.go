@@ -0,0 +1,233 @@
+package posfile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Magic starts every binary position file
+const Magic = "MNCL"
+
+// Version is the current binary format version
+const Version uint8 = 1
+
+// header flags
+const (
+	flagGzip uint8 = 1 << iota
+	flagCounts
+)
+
+// headerSize is the number of bytes following Magic
+const headerSize = 7
+
+// layout computes the fixed width of each packed record.
+// A record is packed as
+//
+//	position  2*(width+1) cells
+//	move      1 byte
+//	moves     bitmask of width bits
+//	result    1 byte
+//	next      2*(width+1) cells
+//	count     4 bytes, only when the header has counts
+//
+// where a cell is 1 byte unless the total stones need 2.
+type layout struct {
+	width  int
+	cell   int
+	mask   int
+	counts bool
+}
+
+func newLayout(h Header) layout {
+	l := layout{width: h.Width, cell: 1, mask: (h.Width + 7) / 8, counts: h.Counts}
+	if 2*h.Width*h.Stones > 0xff {
+		l.cell = 2
+	}
+	return l
+}
+
+func (l layout) positionSize() int {
+	return 2 * (l.width + 1) * l.cell
+}
+
+func (l layout) recordSize() int {
+	size := 2*l.positionSize() + 2 + l.mask
+	if l.counts {
+		size += 4
+	}
+	return size
+}
+
+func (l layout) putPosition(b []byte, p *game.Position) []byte {
+	for r := 0; r < 2; r++ {
+		for _, v := range p.Row[r].Items {
+			if l.cell == 2 {
+				b = append(b, byte(v>>8), byte(v))
+			} else {
+				b = append(b, byte(v))
+			}
+		}
+	}
+	return b
+}
+
+func (l layout) getPosition(b []byte) *game.Position {
+	vals := make([]int, 0, 2*(l.width+1))
+	for i := 0; i < len(b); i += l.cell {
+		if l.cell == 2 {
+			vals = append(vals, int(binary.BigEndian.Uint16(b[i:])))
+		} else {
+			vals = append(vals, int(b[i]))
+		}
+	}
+	return game.CreatePosition(vals...)
+}
+
+// binaryWriter packs records after writing the header
+type binaryWriter struct {
+	buf *bufio.Writer
+	gz  *gzip.Writer
+	w   io.Writer
+	l   layout
+	rec []byte
+}
+
+func newBinaryWriter(w io.Writer, h Header) (*binaryWriter, error) {
+	// a cell is at most 2 bytes so the total stones must fit
+	if h.Width < 1 || h.Width > 0xff || h.Stones < 0 || 2*h.Width*h.Stones > 0xffff {
+		return nil, fmt.Errorf("game %dx%d cannot be stored in binary format", h.Width, h.Stones)
+	}
+	h.Version = Version
+	b := &binaryWriter{buf: bufio.NewWriter(w), l: newLayout(h)}
+	var flags uint8
+	if h.Compressed {
+		flags |= flagGzip
+	}
+	if h.Counts {
+		flags |= flagCounts
+	}
+	hdr := []byte(Magic)
+	hdr = append(hdr, h.Version, byte(h.Width), byte(h.Stones>>8), byte(h.Stones), byte(h.Rules), byte(b.l.cell), flags)
+	if _, err := b.buf.Write(hdr); err != nil {
+		return nil, err
+	}
+	b.w = b.buf
+	if h.Compressed {
+		b.gz = gzip.NewWriter(b.buf)
+		b.w = b.gz
+	}
+	b.rec = make([]byte, 0, b.l.recordSize())
+	return b, nil
+}
+
+// Write packs a single record
+func (b *binaryWriter) Write(rec *Record) error {
+	r := b.l.putPosition(b.rec[:0], rec.Position)
+	r = append(r, byte(rec.Move))
+	mask := make([]byte, b.l.mask)
+	for _, m := range rec.Moves {
+		mask[(m-1)/8] |= 1 << uint((m-1)%8)
+	}
+	r = append(r, mask...)
+	r = append(r, byte(rec.Result))
+	r = b.l.putPosition(r, rec.Next)
+	if b.l.counts {
+		r = append(r, byte(rec.Count>>24), byte(rec.Count>>16), byte(rec.Count>>8), byte(rec.Count))
+	}
+	_, err := b.w.Write(r)
+	return err
+}
+
+// Close flushes any compression and buffering
+func (b *binaryWriter) Close() error {
+	if b.gz != nil {
+		if err := b.gz.Close(); err != nil {
+			return err
+		}
+	}
+	return b.buf.Flush()
+}
+
+// binaryReader unpacks records after reading the header
+type binaryReader struct {
+	r      io.Reader
+	header Header
+	l      layout
+	rec    []byte
+}
+
+func newBinaryReader(r *bufio.Reader) (*binaryReader, error) {
+	hdr := make([]byte, len(Magic)+headerSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	hdr = hdr[len(Magic):]
+	h := Header{
+		Version:    hdr[0],
+		Width:      int(hdr[1]),
+		Stones:     int(hdr[2])<<8 | int(hdr[3]),
+		Rules:      game.Rules(hdr[4]),
+		Compressed: hdr[6]&flagGzip != 0,
+		Counts:     hdr[6]&flagCounts != 0,
+	}
+	if h.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d", h.Version)
+	}
+	// only the standard rules can be replayed
+	if h.Rules != game.StandardRules {
+		return nil, fmt.Errorf("unsupported rules %d", h.Rules)
+	}
+	b := &binaryReader{r: r, header: h, l: newLayout(h)}
+	if int(hdr[5]) != b.l.cell {
+		return nil, fmt.Errorf("unexpected cell size %d", hdr[5])
+	}
+	if h.Compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		b.r = gz
+	}
+	b.rec = make([]byte, b.l.recordSize())
+	game.DefineGame(h.Width, h.Stones)
+	return b, nil
+}
+
+// Header returns the header read from the file
+func (b *binaryReader) Header() Header {
+	return b.header
+}
+
+// Read unpacks the next record
+func (b *binaryReader) Read() (*Record, error) {
+	if _, err := io.ReadFull(b.r, b.rec); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated record")
+		}
+		return nil, err
+	}
+	ps := b.l.positionSize()
+	r := b.rec
+	rec := &Record{Position: b.l.getPosition(r[:ps])}
+	r = r[ps:]
+	rec.Move = int(r[0])
+	rec.Moves = make([]int, 0, b.l.width)
+	for i := 0; i < b.l.width; i++ {
+		if r[1+i/8]&(1<<uint(i%8)) != 0 {
+			rec.Moves = append(rec.Moves, i+1)
+		}
+	}
+	r = r[1+b.l.mask:]
+	rec.Result = game.MoveResult(int8(r[0]))
+	rec.Next = b.l.getPosition(r[1 : 1+ps])
+	if b.l.counts {
+		rec.Count = int(binary.BigEndian.Uint32(r[1+ps:]))
+	}
+	return rec, nil
+}
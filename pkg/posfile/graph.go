@@ -0,0 +1,172 @@
+package posfile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// graphNode is a position seen by the player to move
+type graphNode struct {
+	id       int
+	pos      *game.Position
+	start    bool
+	terminal bool
+	score    int
+}
+
+// label shows the near row above the far row
+func (n *graphNode) label() string {
+	s := fmt.Sprintf("near %s\nfar %s", joinInts(n.pos.Row[0].Items), joinInts(n.pos.Row[1].Items))
+	if n.terminal {
+		s += fmt.Sprintf("\nscore %+d", n.score)
+	}
+	return s
+}
+
+// graphEdge is a move between positions
+type graphEdge struct {
+	from, to int
+	move     int
+	result   game.MoveResult
+	steal    bool
+}
+
+// graphWriter collects the state graph which is written on Close,
+// graphs are write only and intended for small boards
+type graphWriter struct {
+	w      *bufio.Writer
+	format Format
+	header Header
+	ids    map[string]*graphNode
+	nodes  []*graphNode
+	edges  []graphEdge
+}
+
+func newGraphWriter(w io.Writer, format Format, h Header) *graphWriter {
+	return &graphWriter{
+		w:      bufio.NewWriter(w),
+		format: format,
+		header: h,
+		ids:    make(map[string]*graphNode),
+	}
+}
+
+// node finds or adds the node for a position
+func (g *graphWriter) node(p *game.Position) *graphNode {
+	key := p.AsCsv()
+	n, ok := g.ids[key]
+	if !ok {
+		n = &graphNode{id: len(g.nodes), pos: p, start: len(g.nodes) == 0}
+		g.ids[key] = n
+		g.nodes = append(g.nodes, n)
+	}
+	return n
+}
+
+// Write adds the edge for the record, with the successor
+// from the perspective of the next player to move
+func (g *graphWriter) Write(rec *Record) error {
+	from := g.node(rec.Position)
+	next := rec.Next
+	if rec.Result == game.EndOfTurn {
+		next = next.ChangePlayer()
+	}
+	to := g.node(next)
+	if rec.Result == game.EndOfGame {
+		to.terminal = true
+		to.score = next.Row[0].Items[0] - next.Row[1].Items[0]
+	}
+	steal := false
+	if t, err := rec.Position.Play(rec.Move); err == nil {
+		steal = t.Steal
+	}
+	g.edges = append(g.edges, graphEdge{
+		from:   from.id,
+		to:     to.id,
+		move:   rec.Move,
+		result: rec.Result,
+		steal:  steal,
+	})
+	return nil
+}
+
+// Close writes the collected graph
+func (g *graphWriter) Close() error {
+	if g.format == GraphML {
+		g.writeGraphML()
+	} else {
+		g.writeDot()
+	}
+	return g.w.Flush()
+}
+
+// writeDot writes the graph in Graphviz DOT.
+// Repeat turns are dashed blue, steals bold red
+// and terminal positions have a double border.
+func (g *graphWriter) writeDot() {
+	fmt.Fprintf(g.w, "// mancala %dx%d\n", g.header.Width, g.header.Stones)
+	fmt.Fprintf(g.w, "digraph mancala {\n")
+	fmt.Fprintf(g.w, "\tnode [shape=box, fontname=\"Courier\"];\n")
+	for _, n := range g.nodes {
+		attrs := []string{fmt.Sprintf("label=%q", n.label())}
+		if n.start {
+			attrs = append(attrs, "style=bold")
+		}
+		if n.terminal {
+			attrs = append(attrs, "peripheries=2")
+		}
+		fmt.Fprintf(g.w, "\tn%d [%s];\n", n.id, strings.Join(attrs, ", "))
+	}
+	for _, e := range g.edges {
+		attrs := []string{fmt.Sprintf("label=\"%d\"", e.move)}
+		if e.result == game.RepeatTurn {
+			attrs = append(attrs, "style=dashed", "color=blue")
+		}
+		if e.steal {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		fmt.Fprintf(g.w, "\tn%d -> n%d [%s];\n", e.from, e.to, strings.Join(attrs, ", "))
+	}
+	fmt.Fprintf(g.w, "}\n")
+}
+
+// writeGraphML writes the graph with the styling as data attributes
+func (g *graphWriter) writeGraphML() {
+	fmt.Fprintf(g.w, "%s", xml.Header)
+	fmt.Fprintf(g.w, "<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	fmt.Fprintf(g.w, "  <key id=\"label\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	fmt.Fprintf(g.w, "  <key id=\"start\" for=\"node\" attr.name=\"start\" attr.type=\"boolean\"><default>false</default></key>\n")
+	fmt.Fprintf(g.w, "  <key id=\"terminal\" for=\"node\" attr.name=\"terminal\" attr.type=\"boolean\"><default>false</default></key>\n")
+	fmt.Fprintf(g.w, "  <key id=\"score\" for=\"node\" attr.name=\"score\" attr.type=\"int\"/>\n")
+	fmt.Fprintf(g.w, "  <key id=\"move\" for=\"edge\" attr.name=\"move\" attr.type=\"int\"/>\n")
+	fmt.Fprintf(g.w, "  <key id=\"result\" for=\"edge\" attr.name=\"result\" attr.type=\"string\"/>\n")
+	fmt.Fprintf(g.w, "  <key id=\"steal\" for=\"edge\" attr.name=\"steal\" attr.type=\"boolean\"><default>false</default></key>\n")
+	fmt.Fprintf(g.w, "  <graph id=\"mancala-%dx%d\" edgedefault=\"directed\">\n", g.header.Width, g.header.Stones)
+	for _, n := range g.nodes {
+		fmt.Fprintf(g.w, "    <node id=\"n%d\"><data key=\"label\">", n.id)
+		xml.EscapeText(g.w, []byte(n.label()))
+		fmt.Fprintf(g.w, "</data>")
+		if n.start {
+			fmt.Fprintf(g.w, "<data key=\"start\">true</data>")
+		}
+		if n.terminal {
+			fmt.Fprintf(g.w, "<data key=\"terminal\">true</data><data key=\"score\">%d</data>", n.score)
+		}
+		fmt.Fprintf(g.w, "</node>\n")
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(g.w, "    <edge source=\"n%d\" target=\"n%d\"><data key=\"move\">%d</data><data key=\"result\">%s</data>",
+			e.from, e.to, e.move, e.result)
+		if e.steal {
+			fmt.Fprintf(g.w, "<data key=\"steal\">true</data>")
+		}
+		fmt.Fprintf(g.w, "</edge>\n")
+	}
+	fmt.Fprintf(g.w, "  </graph>\n")
+	fmt.Fprintf(g.w, "</graphml>\n")
+}
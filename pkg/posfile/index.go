@@ -0,0 +1,230 @@
+package posfile
+
+import (
+	"os"
+	"sort"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/dgraph-io/badger/v2"
+	bh "github.com/timshannon/badgerhold/v2"
+)
+
+// indexBatch is the number of records inserted per transaction
+const indexBatch = 1000
+
+// headerKey stores the file header within an index
+const headerKey = "header"
+
+// sourceKey stores the source of an index
+const sourceKey = "source"
+
+// source identifies the position file an index was built from
+type source struct {
+	Size    int64
+	ModTime int64
+}
+
+// sourceOf describes a position file by its size and modification time
+func sourceOf(path string) (source, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return source{}, err
+	}
+	return source{Size: info.Size(), ModTime: info.ModTime().UnixNano()}, nil
+}
+
+// Entry is an indexed record
+type Entry struct {
+	// Position is the AsCsv of the position before the move
+	Position string `badgerholdIndex:"Position"`
+	Move     int
+	Moves    []int
+	Result   game.MoveResult
+	// Next is the AsCsv of the position after the move
+	Next string
+	// Successor is the AsCsv of the position the next player moves from
+	Successor string `badgerholdIndex:"Successor"`
+	// Steal when the move captured the opposite hole
+	Steal bool `badgerholdIndex:"Steal"`
+	Count int
+}
+
+// Record converts the entry back into a record
+func (e *Entry) Record() *Record {
+	return &Record{
+		Position: game.CreatePositionCsv(e.Position),
+		Move:     e.Move,
+		Moves:    e.Moves,
+		Result:   e.Result,
+		Next:     game.CreatePositionCsv(e.Next),
+		Count:    e.Count,
+	}
+}
+
+// Index is a badgerhold store of a position file
+// allowing lookups without a full scan
+type Index struct {
+	store  *bh.Store
+	header Header
+}
+
+func openStore(dir string) (*bh.Store, error) {
+	options := bh.DefaultOptions
+	options.Dir = dir
+	options.ValueDir = dir
+	options.Logger = nil
+	return bh.Open(options)
+}
+
+// BuildIndex reads every record into a new index in dir
+func BuildIndex(r Reader, dir string) (*Index, error) {
+	store, err := openStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{store: store, header: r.Header()}
+	if err = store.Upsert(headerKey, idx.header); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	batch := make([]*Entry, 0, indexBatch)
+	id := uint64(0)
+	insert := func() error {
+		err := store.Badger().Update(func(tx *badger.Txn) error {
+			for _, e := range batch {
+				id++
+				if err := store.TxInsert(tx, id, e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		batch = batch[:0]
+		return err
+	}
+	err = Each(r, func(rec *Record) error {
+		batch = append(batch, newEntry(rec))
+		if len(batch) == indexBatch {
+			return insert()
+		}
+		return nil
+	})
+	if err == nil {
+		err = insert()
+	}
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// newEntry computes the indexed fields of a record
+func newEntry(rec *Record) *Entry {
+	succ := rec.Next
+	if rec.Result == game.EndOfTurn {
+		succ = succ.ChangePlayer()
+	}
+	steal := false
+	if t, err := rec.Position.Play(rec.Move); err == nil {
+		steal = t.Steal
+	}
+	return &Entry{
+		Position:  rec.Position.AsCsv(),
+		Move:      rec.Move,
+		Moves:     rec.Moves,
+		Result:    rec.Result,
+		Next:      rec.Next.AsCsv(),
+		Successor: succ.AsCsv(),
+		Steal:     steal,
+		Count:     rec.Count,
+	}
+}
+
+// OpenIndex opens an existing index in dir
+// defining the game dimensions from the indexed file
+func OpenIndex(dir string) (*Index, error) {
+	store, err := openStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{store: store}
+	if err = store.Get(headerKey, &idx.header); err != nil {
+		store.Close()
+		return nil, err
+	}
+	game.DefineGame(idx.header.Width, idx.header.Stones)
+	return idx, nil
+}
+
+// SetSource records the position file at path as the one indexed
+func (i *Index) SetSource(path string) error {
+	s, err := sourceOf(path)
+	if err != nil {
+		return err
+	}
+	return i.store.Upsert(sourceKey, s)
+}
+
+// Matches reports if the index was built from the position file at
+// path as it is now, false for an index without a recorded source
+func (i *Index) Matches(path string) (bool, error) {
+	now, err := sourceOf(path)
+	if err != nil {
+		return false, err
+	}
+	var built source
+	if err := i.store.Get(sourceKey, &built); err == bh.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return built == now, nil
+}
+
+// Header describes the game of the indexed file
+func (i *Index) Header() Header {
+	return i.header
+}
+
+// ByPosition finds the recorded moves from a position
+func (i *Index) ByPosition(p *game.Position) (entries []*Entry, err error) {
+	err = i.store.Find(&entries, bh.Where("Position").Eq(p.AsCsv()).Index("Position"))
+	sortEntries(entries)
+	return
+}
+
+// BySuccessor finds the moves which lead to a position,
+// seen by the player to move
+func (i *Index) BySuccessor(p *game.Position) (entries []*Entry, err error) {
+	err = i.store.Find(&entries, bh.Where("Successor").Eq(p.AsCsv()).Index("Successor"))
+	sortEntries(entries)
+	return
+}
+
+// sortEntries orders by position then move
+func sortEntries(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Position != entries[j].Position {
+			return entries[i].Position < entries[j].Position
+		}
+		return entries[i].Move < entries[j].Move
+	})
+}
+
+// Steals finds moves which capture the opposite hole,
+// limit of zero finds all
+func (i *Index) Steals(limit int) (entries []*Entry, err error) {
+	q := bh.Where("Steal").Eq(true).Index("Steal")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err = i.store.Find(&entries, q)
+	return
+}
+
+// Close closes the underlying store
+func (i *Index) Close() error {
+	return i.store.Close()
+}
@@ -0,0 +1,105 @@
+package posfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 4)
+
+	// start moves and a position with a steal
+	recs := records()
+	steal := game.CreatePosition(0, 0, 1, 3, 0, 4, 4, 4)
+	for _, m := range steal.ValidMoves() {
+		e, _, result, _ := steal.Move(m)
+		recs = append(recs, &Record{Position: steal, Move: m, Moves: steal.ValidMoves(), Result: result, Next: e})
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, Text, NewHeader(false))
+	for _, rec := range recs {
+		w.Write(rec)
+	}
+	w.Close()
+	r, err := NewReader(&buf)
+	assert.Nil(err)
+
+	dir, err := ioutil.TempDir("", "posfile-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	idx, err := BuildIndex(r, dir)
+	assert.Nil(err)
+	idx.Close()
+
+	game.DefineGame(6, 4)
+	idx, err = OpenIndex(dir)
+	assert.Nil(err)
+	defer idx.Close()
+	assert.Equal(3, game.WIDTH())
+
+	entries, err := idx.ByPosition(game.StartPosition())
+	assert.Nil(err)
+	assert.Len(entries, 3)
+
+	// hole 3 ends the turn giving the opponent this position
+	succ := recs[2].Next.ChangePlayer()
+	entries, err = idx.BySuccessor(succ)
+	assert.Nil(err)
+	assert.Len(entries, 1)
+	assert.Equal(3, entries[0].Move)
+	assert.Equal(recs[2].Next, entries[0].Record().Next)
+
+	entries, err = idx.Steals(0)
+	assert.Nil(err)
+	assert.Len(entries, 1)
+	assert.Equal(steal.AsCsv(), entries[0].Position)
+	assert.Equal(2, entries[0].Move)
+}
+
+func TestIndexSource(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	dir, err := ioutil.TempDir("", "posfile-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "positions.txt")
+	assert.Nil(ioutil.WriteFile(path, []byte(FormatLine(records()[0])+"\n"), 0644))
+
+	f, err := os.Open(path)
+	assert.Nil(err)
+	r, err := NewReader(f)
+	assert.Nil(err)
+	idx, err := BuildIndex(r, filepath.Join(dir, "positions.idx"))
+	f.Close()
+	assert.Nil(err)
+	defer idx.Close()
+
+	// an index without a source matches no file
+	ok, err := idx.Matches(path)
+	assert.Nil(err)
+	assert.False(ok)
+
+	assert.Nil(idx.SetSource(path))
+	ok, err = idx.Matches(path)
+	assert.Nil(err)
+	assert.True(ok)
+
+	// regenerating the file leaves the index stale
+	assert.Nil(ioutil.WriteFile(path, []byte(FormatLine(records()[0])+"\n"+FormatLine(records()[1])+"\n"), 0644))
+	ok, err = idx.Matches(path)
+	assert.Nil(err)
+	assert.False(ok)
+
+	_, err = idx.Matches(path + "-missing")
+	assert.NotNil(err)
+}
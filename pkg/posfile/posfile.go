@@ -0,0 +1,170 @@
+// Package posfile reads and writes the position files created by mgenerate.
+//
+// Two formats are supported. The text format has one line per transition
+//
+//	position;move;validmoves;result;next[;count]
+//
+// and the binary format starts with a versioned header recording the
+// dimensions and rules of the game followed by fixed width packed records,
+// optionally gzip compressed.
+//
+// The state graph can also be exported, but not read,
+// as Graphviz DOT or GraphML.
+package posfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Format identifies a position file encoding
+type Format int
+
+const (
+	// Text is one line per transition
+	Text Format = iota
+	// Binary is a header followed by packed records
+	Binary
+	// Dot is a Graphviz digraph of the positions
+	Dot
+	// GraphML is an XML graph of the positions
+	GraphML
+)
+
+// ParseFormat converts a name into a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text", "txt":
+		return Text, nil
+	case "binary", "bin":
+		return Binary, nil
+	case "dot", "gv":
+		return Dot, nil
+	case "graphml":
+		return GraphML, nil
+	}
+	return Text, fmt.Errorf("unknown format %q, must be one of: text, binary, dot, graphml", name)
+}
+
+// String returns the name of a Format
+func (f Format) String() string {
+	switch f {
+	case Binary:
+		return "binary"
+	case Dot:
+		return "dot"
+	case GraphML:
+		return "graphml"
+	}
+	return "text"
+}
+
+// Record is a single transition from a position by a move
+type Record struct {
+	// Position is the position before the move
+	Position *game.Position
+	// Move is the hole played
+	Move int
+	// Moves are all the valid moves from Position
+	Moves []int
+	// Result is the outcome of the move
+	Result game.MoveResult
+	// Next is the position after the move, before any change of player
+	Next *game.Position
+	// Count is the frequency of a sampled transition
+	Count int
+}
+
+// Header describes the game used to generate a file
+type Header struct {
+	// Version of the binary format
+	Version uint8
+	// Width is the size of the board
+	Width int
+	// Stones is the initial number per hole
+	Stones int
+	// Rules used to generate the transitions
+	Rules game.Rules
+	// Compressed when records are gzip compressed
+	Compressed bool
+	// Counts when records have a frequency count
+	Counts bool
+}
+
+// NewHeader creates a header for the currently defined game
+func NewHeader(compressed bool) Header {
+	return Header{
+		Version:    Version,
+		Width:      game.WIDTH(),
+		Stones:     game.STONE(),
+		Rules:      game.StandardRules,
+		Compressed: compressed,
+	}
+}
+
+// NewCountsHeader creates a header for records with a frequency count
+func NewCountsHeader(compressed bool) Header {
+	h := NewHeader(compressed)
+	h.Counts = true
+	return h
+}
+
+// Writer writes records to a position file
+type Writer interface {
+	// Write appends a single record
+	Write(rec *Record) error
+	// Close flushes buffered records, the underlying writer is not closed
+	Close() error
+}
+
+// Reader iterates over the records of a position file
+type Reader interface {
+	// Header describes the game the file was generated from
+	Header() Header
+	// Read returns the next record or io.EOF when done
+	Read() (*Record, error)
+}
+
+// NewWriter creates a Writer for the format
+func NewWriter(w io.Writer, format Format, h Header) (Writer, error) {
+	switch format {
+	case Binary:
+		return newBinaryWriter(w, h)
+	case Dot, GraphML:
+		return newGraphWriter(w, format, h), nil
+	}
+	return newTextWriter(w, h), nil
+}
+
+// NewReader detects the format of r and creates a Reader.
+// The game dimensions are defined from the file
+// so positions read are immediately usable.
+func NewReader(r io.Reader) (Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(Magic))
+	if err == nil && bytes.Equal(magic, []byte(Magic)) {
+		return newBinaryReader(br)
+	}
+	return newTextReader(br)
+}
+
+// Each calls fn for every record until the end of r
+func Each(r Reader, fn func(rec *Record) error) error {
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(rec); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package posfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// records creates the transitions from the start position
+func records() (recs []*Record) {
+	p := game.StartPosition()
+	moves := p.ValidMoves()
+	for _, m := range moves {
+		e, _, result, _ := p.Move(m)
+		recs = append(recs, &Record{
+			Position: p,
+			Move:     m,
+			Moves:    moves,
+			Result:   result,
+			Next:     e,
+		})
+	}
+	return
+}
+
+func roundTrip(t *testing.T, format Format, h Header) {
+	assert := assert.New(t)
+	recs := records()
+	if h.Counts {
+		for i, rec := range recs {
+			rec.Count = i + 1
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, h)
+	assert.Nil(err)
+	for _, rec := range recs {
+		assert.Nil(w.Write(rec))
+	}
+	assert.Nil(w.Close())
+
+	r, err := NewReader(&buf)
+	assert.Nil(err)
+	assert.Equal(game.WIDTH(), r.Header().Width)
+	assert.Equal(game.STONE(), r.Header().Stones)
+	assert.Equal(h.Counts, r.Header().Counts)
+	var got []*Record
+	assert.Nil(Each(r, func(rec *Record) error {
+		got = append(got, rec)
+		return nil
+	}))
+	assert.True(cmp.Equal(recs, got))
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	game.DefineGame(3, 2)
+	roundTrip(t, Text, NewHeader(false))
+	roundTrip(t, Text, NewCountsHeader(false))
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	game.DefineGame(6, 4)
+	roundTrip(t, Binary, NewHeader(false))
+	roundTrip(t, Binary, NewHeader(true))
+	roundTrip(t, Binary, NewCountsHeader(true))
+}
+
+func TestBinaryWideCells(t *testing.T) {
+	game.DefineGame(10, 20)
+	roundTrip(t, Binary, NewCountsHeader(false))
+}
+
+func TestBinaryLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	// the total stones must fit a 2 byte cell
+	game.DefineGame(20, 2000)
+	_, err := NewWriter(&bytes.Buffer{}, Binary, NewHeader(false))
+	assert.EqualError(err, "game 20x2000 cannot be stored in binary format")
+
+	// a file of other rules is not replayed
+	game.DefineGame(3, 2)
+	var buf bytes.Buffer
+	h := NewHeader(false)
+	h.Rules = game.RuleRepeatTurn
+	w, err := NewWriter(&buf, Binary, h)
+	assert.Nil(err)
+	assert.Nil(w.Close())
+	_, err = NewReader(&buf)
+	assert.EqualError(err, "unsupported rules 1")
+}
+
+func TestTextLine(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	rec := records()[2]
+	s := FormatLine(rec)
+	assert.Equal("0,2,2,2,0,2,2,2;3;1,2,3;0;0,3,3,0,0,2,2,2", s)
+
+	parsed, err := ParseLine(s)
+	assert.Nil(err)
+	assert.True(cmp.Equal(rec, parsed))
+
+	_, err = ParseLine("0,2,2,2,0,2,2,2;3")
+	assert.NotNil(err)
+
+	// malformed and extra cells are rejected
+	_, err = ParseLine("0,2,x,2,0,2,2,2;3;1,2,3;0;0,3,3,0,0,2,2,2")
+	assert.EqualError(err, `position: "x" is not a count of stones`)
+	_, err = ParseLine("0,2,2,2,0,2,2,2;3;1,2,3;0;0,3,3,0,0,2,2,2,1")
+	assert.EqualError(err, "next: expected 8 cells, found 9")
+	_, err = ParseLine("0,2,2,2,0,2,2,-2;3;1,2,3;0;0,3,3,0,0,2,2,2")
+	assert.EqualError(err, `position: "-2" is not a count of stones`)
+}
+
+func TestTextInfersGame(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	r, err := NewReader(bytes.NewBufferString("0,2,2,2,0,2,2,2;1;1,2,3;1;1,1,2,2,0,2,2,2\n"))
+	assert.Nil(err)
+	assert.Equal(3, r.Header().Width)
+	assert.Equal(2, r.Header().Stones)
+	assert.Equal(3, game.WIDTH())
+}
+
+func TestGraph(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	for _, format := range []Format{Dot, GraphML} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format, NewHeader(false))
+		assert.Nil(err)
+		for _, rec := range records() {
+			assert.Nil(w.Write(rec))
+		}
+		assert.Nil(w.Close())
+
+		out := buf.String()
+		if format == Dot {
+			assert.Contains(out, "digraph mancala {")
+			assert.Contains(out, "n0 -> n1 [label=\"1\"];")
+			assert.Contains(out, "n0 -> n2 [label=\"2\", style=dashed, color=blue];")
+		} else {
+			assert.Contains(out, "<graph id=\"mancala-3x2\" edgedefault=\"directed\">")
+			assert.Contains(out, "<data key=\"result\">RepeatTurn</data>")
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	in := "0,2,2,2,0,2,2,2;1;1,2,3;0;1,0,2,2,0,2,2,3\n" +
+		"0,2,2,2,0,2,2,2;2;1,2;0;1,3,0,2,0,2,2,2\n" +
+		"0,2,2,2,0,2,2,2;3;1,2,3;0;0,3,3,0,0,2,2,3\n" +
+		"0,0,2,2,0,2,2,2;1;2,3;0;0,0,2,2,0,2,2,2\n"
+	r, err := NewReader(bytes.NewBufferString(in))
+	assert.Nil(err)
+
+	var diffs []Divergence
+	records, divergent, err := Validate(r, func(d Divergence) {
+		diffs = append(diffs, d)
+	})
+	assert.Nil(err)
+	assert.Equal(4, records)
+	assert.Equal(3, divergent)
+	assert.Len(diffs, 4)
+
+	assert.Equal(2, diffs[0].Index)
+	assert.Equal("moves", diffs[0].Field)
+	assert.Equal("1,2", diffs[0].Recorded)
+	assert.Equal("1,2,3", diffs[0].Expected)
+	assert.Equal("result", diffs[1].Field)
+	assert.Equal("RepeatTurn", diffs[1].Expected)
+	assert.Equal("next", diffs[2].Field)
+	assert.Equal(3, diffs[2].Index)
+	assert.Equal("move", diffs[3].Field)
+	assert.Equal("record 4: 0,0,2,2,0,2,2,2;1 move recorded 1 expected invalid move", diffs[3].String())
+
+	// a malformed position stops validation
+	r, err = NewReader(bytes.NewBufferString(in + "0,2,2,2,0,2,2;1;1,2,3;0;1,0,2,2,0,2,2,3\n"))
+	assert.Nil(err)
+	records, _, err = Validate(r, func(d Divergence) {})
+	assert.Equal(4, records)
+	assert.NotNil(err)
+}
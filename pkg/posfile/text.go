@@ -0,0 +1,191 @@
+package posfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// textWriter writes one line per record
+type textWriter struct {
+	w      *bufio.Writer
+	counts bool
+}
+
+func newTextWriter(w io.Writer, h Header) *textWriter {
+	return &textWriter{w: bufio.NewWriter(w), counts: h.Counts}
+}
+
+// Write appends a line for the record
+func (t *textWriter) Write(rec *Record) error {
+	line := FormatLine(rec)
+	if t.counts {
+		line = fmt.Sprintf("%s;%d", line, rec.Count)
+	}
+	_, err := t.w.WriteString(line + "\n")
+	return err
+}
+
+// Close flushes the buffered lines
+func (t *textWriter) Close() error {
+	return t.w.Flush()
+}
+
+// FormatLine returns the text representation of a record
+func FormatLine(rec *Record) string {
+	return fmt.Sprintf("%s;%d;%s;%d;%s",
+		rec.Position.AsCsv(),
+		rec.Move,
+		joinInts(rec.Moves),
+		rec.Result,
+		rec.Next.AsCsv(),
+	)
+}
+
+// joinInts formats values separated by commas
+func joinInts(vals []int) string {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseLine converts a text line, with an optional count, into a record,
+// the game must already be defined
+func ParseLine(line string) (*Record, error) {
+	s := strings.Split(strings.TrimRight(line, "\r\n"), ";")
+	if len(s) != 5 && len(s) != 6 {
+		return nil, fmt.Errorf("expected 5 or 6 fields, found %d", len(s))
+	}
+	move, err := strconv.Atoi(s[1])
+	if err != nil {
+		return nil, fmt.Errorf("move: %v", err)
+	}
+	moves := make([]int, 0)
+	if s[2] != "" {
+		for _, v := range strings.Split(s[2], ",") {
+			m, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("valid moves: %v", err)
+			}
+			moves = append(moves, m)
+		}
+	}
+	result, err := strconv.Atoi(s[3])
+	if err != nil {
+		return nil, fmt.Errorf("result: %v", err)
+	}
+	pos, err := parsePosition(s[0])
+	if err != nil {
+		return nil, fmt.Errorf("position: %v", err)
+	}
+	next, err := parsePosition(s[4])
+	if err != nil {
+		return nil, fmt.Errorf("next: %v", err)
+	}
+	rec := &Record{
+		Position: pos,
+		Move:     move,
+		Moves:    moves,
+		Result:   game.MoveResult(result),
+		Next:     next,
+	}
+	if len(s) == 6 {
+		if rec.Count, err = strconv.Atoi(s[5]); err != nil {
+			return nil, fmt.Errorf("count: %v", err)
+		}
+	}
+	return rec, nil
+}
+
+// parsePosition reads a position csv of the defined game,
+// every cell being a count of stones
+func parsePosition(csv string) (*game.Position, error) {
+	cells := strings.Split(csv, ",")
+	if n := 2 * (game.WIDTH() + 1); len(cells) != n {
+		return nil, fmt.Errorf("expected %d cells, found %d", n, len(cells))
+	}
+	vals := make([]int, len(cells))
+	for i, c := range cells {
+		v, err := strconv.Atoi(c)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("%q is not a count of stones", c)
+		}
+		vals[i] = v
+	}
+	return game.CreatePosition(vals...), nil
+}
+
+// textReader reads one record per line
+type textReader struct {
+	r      *bufio.Reader
+	header Header
+	first  string
+	line   int
+}
+
+// newTextReader reads ahead the first line to
+// infer the game dimensions which are then defined
+func newTextReader(r *bufio.Reader) (*textReader, error) {
+	t := &textReader{r: r}
+	first, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	t.first = first
+	if first == "" {
+		// empty file, keep the current game
+		t.header = NewHeader(false)
+		return t, nil
+	}
+	fields := strings.Split(strings.TrimRight(first, "\r\n"), ";")
+	csv := fields[0]
+	cells := strings.Split(csv, ",")
+	width := len(cells)/2 - 1
+	if width < 1 {
+		return nil, fmt.Errorf("line 1: cannot infer width from %q", csv)
+	}
+	sum := 0
+	for _, c := range cells {
+		v, err := strconv.Atoi(c)
+		if err != nil {
+			return nil, fmt.Errorf("line 1: %v", err)
+		}
+		sum += v
+	}
+	game.DefineGame(width, sum/(2*width))
+	t.header = NewHeader(false)
+	t.header.Version = 0
+	t.header.Counts = len(fields) == 6
+	return t, nil
+}
+
+// Header returns the dimensions inferred from the first line
+func (t *textReader) Header() Header {
+	return t.header
+}
+
+// Read parses the next line
+func (t *textReader) Read() (*Record, error) {
+	var line string
+	var err error
+	if t.first != "" {
+		line, t.first = t.first, ""
+	} else {
+		line, err = t.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+	}
+	t.line++
+	rec, err := ParseLine(line)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", t.line, err)
+	}
+	return rec, nil
+}
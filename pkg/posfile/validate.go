@@ -0,0 +1,69 @@
+package posfile
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Divergence is a record which disagrees with the engine
+type Divergence struct {
+	// Index is the 1 based number of the record in the file
+	Index int
+	// Record as read from the file
+	Record *Record
+	// Field which disagrees, one of move, moves, result or next
+	Field string
+	// Recorded value in the file
+	Recorded string
+	// Expected value from the engine
+	Expected string
+}
+
+// String describes the divergence
+func (d Divergence) String() string {
+	return fmt.Sprintf("record %d: %s;%d %s recorded %s expected %s",
+		d.Index, d.Record.Position.AsCsv(), d.Record.Move, d.Field, d.Recorded, d.Expected)
+}
+
+// Check replays a record through Move returning where it disagrees
+func Check(rec *Record) (diffs []Divergence) {
+	add := func(field, recorded, expected string) {
+		diffs = append(diffs, Divergence{Record: rec, Field: field, Recorded: recorded, Expected: expected})
+	}
+
+	moves := joinInts(rec.Position.ValidMoves())
+	if recorded := joinInts(rec.Moves); recorded != moves {
+		add("moves", recorded, moves)
+	}
+
+	next, _, result, err := rec.Position.Move(rec.Move)
+	if err != nil {
+		add("move", strconv.Itoa(rec.Move), err.Error())
+		return
+	}
+	if rec.Result != result {
+		add("result", rec.Result.String(), result.String())
+	}
+	if rec.Next.AsCsv() != next.AsCsv() {
+		add("next", rec.Next.AsCsv(), next.AsCsv())
+	}
+	return
+}
+
+// Validate checks every record calling fn for each divergence,
+// returning the number of records and divergent records
+func Validate(r Reader, fn func(d Divergence)) (records int, divergent int, err error) {
+	err = Each(r, func(rec *Record) error {
+		records++
+		diffs := Check(rec)
+		if len(diffs) > 0 {
+			divergent++
+		}
+		for _, d := range diffs {
+			d.Index = records
+			fn(d)
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,226 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// quitGrace is how long an engine has to exit after quit
+const quitGrace = 3 * time.Second
+
+// Engine is an external engine process spoken
+// to over its stdin and stdout
+type Engine struct {
+	// Name and Author are given by the engine in the handshake
+	Name   string
+	Author string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+	// exit is the result of the process, set before lines is closed
+	exit error
+	// failed stops further use once the engine is in an unknown state
+	failed error
+}
+
+// StartEngine launches a command, its stderr passed through,
+// completing the handshake within the timeout
+func StartEngine(command []string, timeout time.Duration) (*Engine, error) {
+	if len(command) == 0 {
+		return nil, errors.New("engine: no command")
+	}
+	e := &Engine{
+		Name:  command[0],
+		cmd:   exec.Command(command[0], command[1:]...),
+		lines: make(chan string),
+	}
+	e.cmd.Stderr = os.Stderr
+	var err error
+	if e.stdin, err = e.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	stdout, err := e.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = e.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("engine: %v", err)
+	}
+	go e.read(stdout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err = e.send("%s %d", CmdMancala, Version); err != nil {
+		return nil, err
+	}
+	for {
+		c, err := e.next(ctx)
+		if err != nil {
+			e.fail(err)
+			return nil, fmt.Errorf("engine %s: handshake: %v", e.Name, err)
+		}
+		switch c.Name {
+		case RespID:
+			if len(c.Args) > 1 && c.Args[0] == "name" {
+				e.Name = strings.Join(c.Args[1:], " ")
+			}
+			if len(c.Args) > 1 && c.Args[0] == "author" {
+				e.Author = strings.Join(c.Args[1:], " ")
+			}
+		case RespMancalaOk:
+			return e, nil
+		}
+	}
+}
+
+// read passes each line of the engines stdout to lines,
+// closing it once the process has exited
+func (e *Engine) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		e.lines <- scanner.Text()
+	}
+	e.exit = e.cmd.Wait()
+	close(e.lines)
+}
+
+// send writes a single command line
+func (e *Engine) send(format string, args ...interface{}) error {
+	if e.failed != nil {
+		return e.failed
+	}
+	if _, err := fmt.Fprintf(e.stdin, format+"\n", args...); err != nil {
+		e.fail(fmt.Errorf("engine %s: %v", e.Name, err))
+		return e.failed
+	}
+	return nil
+}
+
+// next reads the next response, skipping info and blank lines
+func (e *Engine) next(ctx context.Context) (Command, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return Command{}, ctx.Err()
+		case line, ok := <-e.lines:
+			if !ok {
+				if e.exit != nil {
+					return Command{}, fmt.Errorf("engine exited: %v", e.exit)
+				}
+				return Command{}, errors.New("engine exited")
+			}
+			c := ParseCommand(line)
+			if c.Name != "" && c.Name != RespInfo {
+				return c, nil
+			}
+		}
+	}
+}
+
+// expect reads responses until the named one, or an error
+func (e *Engine) expect(ctx context.Context, name string) (Command, error) {
+	for {
+		c, err := e.next(ctx)
+		if err != nil {
+			e.fail(fmt.Errorf("engine %s: waiting for %s: %v", e.Name, name, err))
+			return c, e.failed
+		}
+		if c.Name == RespError {
+			e.fail(fmt.Errorf("engine %s: %s", e.Name, strings.Join(c.Args, " ")))
+			return c, e.failed
+		}
+		if c.Name == name {
+			return c, nil
+		}
+	}
+}
+
+// fail records the first failure and stops the process,
+// discarding anything it has still to say
+func (e *Engine) fail(err error) {
+	if e.failed != nil {
+		return
+	}
+	e.failed = err
+	e.cmd.Process.Kill()
+	go func() {
+		for range e.lines {
+		}
+	}()
+}
+
+// NewGame sends the rules of the current game and waits
+// for the engine to be ready
+func (e *Engine) NewGame(timeout time.Duration) error {
+	if err := e.send(FormatRules()); err != nil {
+		return err
+	}
+	if err := e.send(CmdNewGame); err != nil {
+		return err
+	}
+	if err := e.send(CmdIsReady); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := e.expect(ctx, RespReadyOk)
+	return err
+}
+
+// BestMove asks the engine to move from a position, which fails
+// if the context is done first. Resigning returns game.ErrResign.
+func (e *Engine) BestMove(ctx context.Context, pos *game.Position, movetime time.Duration) (int, error) {
+	if err := e.send("%s %s", CmdPosition, pos.AsCsv()); err != nil {
+		return 0, err
+	}
+	if err := e.send("%s movetime %d", CmdGo, movetime.Milliseconds()); err != nil {
+		return 0, err
+	}
+	c, err := e.expect(ctx, RespBestMove)
+	if err != nil {
+		return 0, err
+	}
+	if len(c.Args) == 0 {
+		return 0, fmt.Errorf("engine %s: bestmove without a move", e.Name)
+	}
+	if c.Args[0] == Resign {
+		return 0, game.ErrResign
+	}
+	hole, err := strconv.Atoi(c.Args[0])
+	if err != nil {
+		return 0, fmt.Errorf("engine %s: bestmove %q is not a hole", e.Name, c.Args[0])
+	}
+	return hole, nil
+}
+
+// Close asks the engine to quit, stopping it if it does not
+func (e *Engine) Close() error {
+	if e.failed == nil {
+		e.send(CmdQuit)
+	}
+	e.stdin.Close()
+	timer := time.NewTimer(quitGrace)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-e.lines:
+			if !ok {
+				return nil
+			}
+		case <-timer.C:
+			e.fail(fmt.Errorf("engine %s: did not quit", e.Name))
+			return e.failed
+		}
+	}
+}
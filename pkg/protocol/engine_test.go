@@ -0,0 +1,148 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain runs the test binary as a stub engine when asked
+func TestMain(m *testing.M) {
+	if len(os.Args) == 3 && os.Args[1] == "stub-engine" {
+		stubEngine(os.Args[2])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stubEngine plays the first valid move, misbehaving as the mode asks
+func stubEngine(mode string) {
+	var pos *game.Position
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		c := ParseCommand(scanner.Text())
+		switch c.Name {
+		case CmdMancala:
+			fmt.Println("id name stub", mode)
+			fmt.Println("id author test")
+			fmt.Println(RespMancalaOk)
+		case CmdRules:
+			ParseRules(c)
+		case CmdIsReady:
+			fmt.Println(RespReadyOk)
+		case CmdPosition:
+			pos, _ = ParsePosition(c.Args[0])
+		case CmdGo:
+			fmt.Println("info thinking")
+			switch mode {
+			case "slow":
+				time.Sleep(time.Minute)
+			case "crash":
+				os.Exit(3)
+			case "invalid":
+				fmt.Println("bestmove 99")
+			case "resign":
+				fmt.Println("bestmove resign")
+			default:
+				fmt.Println("bestmove", pos.ValidMoves()[0])
+			}
+		case CmdQuit:
+			return
+		}
+	}
+}
+
+// stub creates an external player of the test binary
+func stub(t *testing.T, mode string, movetime string) game.Agent {
+	a, err := game.CreateAgent(map[string]string{
+		"type":     "external",
+		"command":  os.Args[0] + " stub-engine " + mode,
+		"movetime": movetime,
+		"margin":   "100",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestExternalPlayer(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	a := stub(t, "first", "10")
+	p := a.(*ExternalPlayer)
+	defer p.Close()
+	assert.Equal("stub first", p.Engine.Name)
+	assert.Equal("test", p.Engine.Author)
+
+	random, _ := game.CreateAgent(map[string]string{"type": "random", "quiet": "true", "seed": "1"})
+	result, err := game.PlayGame(context.Background(), [2]game.Agent{a, random}, game.StartPosition(), nil)
+	assert.Nil(err)
+	assert.True(result.Plies > 0)
+	assert.Nil(p.Close())
+}
+
+func TestExternalFailures(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+	start := game.StartPosition()
+
+	slow := stub(t, "slow", "10")
+	_, err := slow.Move(context.Background(), start)
+	assert.EqualError(err, "engine stub slow: no bestmove within 110ms")
+	// the engine is stopped and stays failed
+	_, err = slow.Move(context.Background(), start)
+	assert.NotNil(err)
+	slow.(*ExternalPlayer).Close()
+
+	crash := stub(t, "crash", "10")
+	_, err = crash.Move(context.Background(), start)
+	assert.EqualError(err, "engine stub crash: waiting for bestmove: engine exited: exit status 3")
+	crash.(*ExternalPlayer).Close()
+
+	invalid := stub(t, "invalid", "10")
+	_, err = invalid.Move(context.Background(), start)
+	assert.EqualError(err, "engine stub invalid: bestmove 99 is not valid from 0,4,4,4,4,4,4,0,4,4,4,4,4,4")
+	invalid.(*ExternalPlayer).Close()
+
+	resign := stub(t, "resign", "10")
+	_, err = resign.Move(context.Background(), start)
+	assert.True(errors.Is(err, game.ErrResign))
+	resign.(*ExternalPlayer).Close()
+
+	_, err = game.CreateAgent(map[string]string{"type": "external"})
+	assert.EqualError(err, "player type external: option command is required")
+	_, err = game.CreateAgent(map[string]string{"type": "external", "command": "/nonexistent/engine"})
+	assert.NotNil(err)
+}
+
+func TestParsePosition(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 2)
+
+	p, err := ParsePosition("1,2,0,3,0,2,2,2")
+	assert.Nil(err)
+	assert.Equal("1,2,0,3,0,2,2,2", p.AsCsv())
+
+	_, err = ParsePosition("1,2,0,3")
+	assert.EqualError(err, "position: expected 8 values, found 4")
+	_, err = ParsePosition("1,2,0,3,0,2,2,-2")
+	assert.EqualError(err, `position: "-2" is not a count of stones`)
+
+	c := ParseCommand("go movetime 250")
+	v, ok, err := c.IntValue("movetime")
+	assert.Equal(250, v)
+	assert.True(ok)
+	assert.Nil(err)
+	assert.Nil(ParseRules(ParseCommand("rules width 4 stones 5")))
+	assert.Equal(4, game.WIDTH())
+	assert.Equal("rules width 4 stones 5", FormatRules())
+}
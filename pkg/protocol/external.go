@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// ExternalPlayer is an Agent played by an engine in another process
+type ExternalPlayer struct {
+	Engine *Engine
+	// MoveTime is the time the engine is asked to move in,
+	// unless a clock gives a budget
+	MoveTime time.Duration
+	// Margin is allowed on top of MoveTime
+	Margin time.Duration
+	// Ready is allowed for the engine to be ready for a new game
+	Ready time.Duration
+
+	started bool
+}
+
+func newExternalPlayer(opts game.Options) (game.Agent, error) {
+	command := strings.Fields(opts.String("command"))
+	if len(command) == 0 {
+		return nil, fmt.Errorf("player type external: option command is required")
+	}
+	startup := time.Duration(opts.Int("startup")) * time.Millisecond
+	e, err := StartEngine(command, startup)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalPlayer{
+		Engine:   e,
+		MoveTime: time.Duration(opts.Int("movetime")) * time.Millisecond,
+		Margin:   time.Duration(opts.Int("margin")) * time.Millisecond,
+		Ready:    startup,
+	}, nil
+}
+
+// NewGame tells the engine the rules, any failure
+// being reported by the next move
+func (p *ExternalPlayer) NewGame(start *game.Position, side int) {
+	p.started = p.Engine.NewGame(p.Ready) == nil
+}
+
+// Move asks the engine for its best move, which must
+// arrive in time and be valid
+func (p *ExternalPlayer) Move(ctx context.Context, pos *game.Position) (int, error) {
+	if !p.started {
+		if err := p.Engine.NewGame(p.Ready); err != nil {
+			return 0, err
+		}
+		p.started = true
+	}
+	movetime := p.MoveTime
+	if c, ok := game.ClockFrom(ctx); ok && c.Budget() > 0 {
+		movetime = c.Budget()
+	}
+	limit := movetime + p.Margin
+	mctx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+	hole, err := p.Engine.BestMove(mctx, pos, movetime)
+	if err != nil {
+		if mctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return 0, fmt.Errorf("engine %s: no bestmove within %v", p.Engine.Name, limit)
+		}
+		return 0, err
+	}
+	for _, m := range pos.ValidMoves() {
+		if hole == m {
+			return hole, nil
+		}
+	}
+	return 0, fmt.Errorf("engine %s: bestmove %d is not valid from %s", p.Engine.Name, hole, pos.AsCsv())
+}
+
+// Close stops the engine
+func (p *ExternalPlayer) Close() error {
+	return p.Engine.Close()
+}
+
+func init() {
+	game.RegisterPlayerType(game.PlayerType{
+		Name:        "external",
+		Description: "an engine in another process speaking the engine protocol",
+		Params: []game.Param{
+			{Name: "command", Type: game.StringParam, Description: "command and arguments to start the engine"},
+			{Name: "movetime", Type: game.IntParam, Default: "1000", Min: 1, Max: 3600000, Description: "milliseconds to move in"},
+			{Name: "margin", Type: game.IntParam, Default: "1000", Min: 0, Max: 60000, Description: "milliseconds allowed over movetime"},
+			{Name: "startup", Type: game.IntParam, Default: "5000", Min: 1, Max: 60000, Description: "milliseconds to start and be ready for a game"},
+		},
+		Agent: newExternalPlayer,
+	})
+}
@@ -0,0 +1,144 @@
+// Package protocol is a line based text protocol for playing
+// an engine in another process, similar in spirit to UCI.
+//
+// The host writes commands to the engines stdin and reads
+// responses from its stdout, one per line:
+//
+//	host                         engine
+//	mancala 1
+//	                             id name <name>
+//	                             id author <author>
+//	                             mancalaok
+//	rules width 6 stones 4
+//	newgame
+//	isready
+//	                             readyok
+//	position 0,4,4,4,4,4,4,0,4,4,4,4,4,4
+//	go movetime 1000
+//	                             info <text>
+//	                             bestmove 3
+//	quit
+//
+// A position is in AsCsv order from the side of the player to move,
+// its home and holes followed by the opponents home and holes.
+// The engine answers go with bestmove and a hole, or bestmove resign,
+// and a command it understands but cannot carry out with error and
+// a message, which the host treats as a failure of the engine.
+// Lines which are not understood are ignored by both sides,
+// as are info lines, which an engine may write at any time.
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Version of the protocol sent in the handshake
+const Version = 1
+
+// Commands sent by the host
+const (
+	CmdMancala  = "mancala"
+	CmdRules    = "rules"
+	CmdNewGame  = "newgame"
+	CmdIsReady  = "isready"
+	CmdPosition = "position"
+	CmdGo       = "go"
+	CmdQuit     = "quit"
+)
+
+// Responses sent by the engine
+const (
+	RespID        = "id"
+	RespMancalaOk = "mancalaok"
+	RespReadyOk   = "readyok"
+	RespInfo      = "info"
+	RespBestMove  = "bestmove"
+)
+
+// Resign is the bestmove giving up the game
+const Resign = "resign"
+
+// Command is a single protocol line split into words
+type Command struct {
+	Name string
+	Args []string
+}
+
+// ParseCommand splits a line into its command and arguments
+func ParseCommand(line string) Command {
+	f := strings.Fields(line)
+	if len(f) == 0 {
+		return Command{}
+	}
+	return Command{Name: f[0], Args: f[1:]}
+}
+
+// Value finds the argument following a keyword, as in go movetime 1000
+func (c Command) Value(key string) (string, bool) {
+	for i := 0; i+1 < len(c.Args); i++ {
+		if c.Args[i] == key {
+			return c.Args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// IntValue finds the whole number following a keyword
+func (c Command) IntValue(key string) (int, bool, error) {
+	s, ok := c.Value(key)
+	if !ok {
+		return 0, false, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, true, fmt.Errorf("%s %s: %q is not an int", c.Name, key, s)
+	}
+	return i, true, nil
+}
+
+// FormatRules describes the current game
+func FormatRules() string {
+	return fmt.Sprintf("%s width %d stones %d", CmdRules, game.WIDTH(), game.STONE())
+}
+
+// ParseRules defines the game described by a rules command
+func ParseRules(c Command) error {
+	width, ok, err := c.IntValue("width")
+	if err != nil {
+		return err
+	}
+	if !ok || width < 1 {
+		return fmt.Errorf("rules: width must be at least 1")
+	}
+	stones, ok, err := c.IntValue("stones")
+	if err != nil {
+		return err
+	}
+	if !ok || stones < 1 {
+		return fmt.Errorf("rules: stones must be at least 1")
+	}
+	game.DefineGame(width, stones)
+	return nil
+}
+
+// ParsePosition strictly converts an AsCsv string
+// into a position of the current game
+func ParsePosition(csv string) (*game.Position, error) {
+	s := strings.Split(csv, ",")
+	if len(s) != 2*(game.WIDTH()+1) {
+		return nil, fmt.Errorf("position: expected %d values, found %d", 2*(game.WIDTH()+1), len(s))
+	}
+	vals := make([]int, len(s))
+	for i, v := range s {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("position: %q is not a count of stones", v)
+		}
+		vals[i] = n
+	}
+	return game.CreatePosition(vals...), nil
+}
@@ -0,0 +1,267 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Commands extending the protocol when serving the Go engine
+const (
+	CmdMoves  = "moves"
+	CmdMove   = "move"
+	CmdShow   = "show"
+	CmdPlayer = "player"
+)
+
+// Responses extending the protocol when serving the Go engine
+const (
+	RespMoves  = "moves"
+	RespMoved  = "moved"
+	RespPlayer = "player"
+	RespError  = "error"
+)
+
+// StartPos is a position argument for the start of the game
+const StartPos = "startpos"
+
+// PlayerConf creates the configuration of a player type
+// from name=value options
+type PlayerConf func(playerType string, options []string) (map[string]string, error)
+
+// Server answers the protocol for the Go engine, best moves
+// coming from any registered player type
+type Server struct {
+	// Name is given in the handshake
+	Name string
+	// Conf configures the player chosen by the player command
+	Conf PlayerConf
+
+	out    *bufio.Writer
+	pos    *game.Position
+	over   bool
+	player string
+	agent  game.Agent
+}
+
+// NewServer creates a server for the current game,
+// conf being used for the initial player type
+func NewServer(name string, conf PlayerConf, playerType string, options []string) (*Server, error) {
+	s := &Server{Name: name, Conf: conf, pos: game.StartPosition()}
+	if err := s.setPlayer(playerType, options); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// setPlayer replaces the player choosing best moves
+func (s *Server) setPlayer(playerType string, options []string) error {
+	if playerType == "console" {
+		return errors.New("player type console cannot be served")
+	}
+	conf, err := s.Conf(playerType, options)
+	if err != nil {
+		return err
+	}
+	agent, err := game.CreateAgent(conf)
+	if err != nil {
+		return err
+	}
+	s.Close()
+	s.player, s.agent = playerType, agent
+	return nil
+}
+
+// Close releases the player
+func (s *Server) Close() error {
+	if c, ok := s.agent.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Serve reads commands until quit or the end of input,
+// writing one response line for each query
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		c := ParseCommand(scanner.Text())
+		if c.Name == CmdQuit {
+			break
+		}
+		if err := s.handle(c); err != nil {
+			s.respond("%s %v", RespError, err)
+		}
+		if err := s.out.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// respond writes a single response line
+func (s *Server) respond(format string, args ...interface{}) {
+	fmt.Fprintf(s.out, format+"\n", args...)
+}
+
+// handle a single command
+func (s *Server) handle(c Command) error {
+	switch c.Name {
+	case "":
+	case CmdMancala:
+		s.respond("%s name %s", RespID, s.Name)
+		s.respond(RespMancalaOk)
+	case CmdIsReady:
+		s.respond(RespReadyOk)
+	case CmdRules:
+		if err := ParseRules(c); err != nil {
+			return err
+		}
+		s.pos, s.over = game.StartPosition(), false
+	case CmdNewGame:
+		s.pos, s.over = game.StartPosition(), false
+		if h, ok := s.agent.(game.GameStarter); ok {
+			h.NewGame(s.pos, 0)
+		}
+	case CmdPosition:
+		return s.position(c.Args)
+	case CmdShow:
+		s.respond("%s %s", CmdPosition, s.pos.AsCsv())
+	case CmdMoves:
+		s.respond("%s", strings.TrimSpace(RespMoves+" "+joinInts(s.moves())))
+	case CmdMove:
+		if len(c.Args) != 1 {
+			return errors.New("move: expected a hole")
+		}
+		hole, err := strconv.Atoi(c.Args[0])
+		if err != nil {
+			return fmt.Errorf("move: %q is not a hole", c.Args[0])
+		}
+		result, err := s.play(hole)
+		if err != nil {
+			return err
+		}
+		s.respond("%s %d %s %s", RespMoved, hole, result, s.pos.AsCsv())
+	case CmdGo:
+		return s.bestMove(c)
+	case CmdPlayer:
+		if len(c.Args) == 0 {
+			s.respond("%s %s", RespPlayer, s.player)
+			return nil
+		}
+		if err := s.setPlayer(c.Args[0], c.Args[1:]); err != nil {
+			return err
+		}
+		s.respond("%s %s", RespPlayer, s.player)
+	default:
+		// commands not understood are ignored
+	}
+	return nil
+}
+
+// position sets the position from a csv or startpos,
+// followed by any moves to play
+func (s *Server) position(args []string) error {
+	if len(args) == 0 {
+		return errors.New("position: expected a csv or startpos")
+	}
+	pos := game.StartPosition()
+	if args[0] != StartPos {
+		var err error
+		if pos, err = ParsePosition(args[0]); err != nil {
+			return err
+		}
+	}
+	s.pos, s.over = pos, pos.IsGameEnd()
+	if len(args) == 1 {
+		return nil
+	}
+	if args[1] != CmdMoves {
+		return fmt.Errorf("position: unexpected %s", args[1])
+	}
+	for _, m := range args[2:] {
+		hole, err := strconv.Atoi(m)
+		if err != nil {
+			return fmt.Errorf("position: %q is not a hole", m)
+		}
+		if _, err = s.play(hole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moves lists the valid moves, none once the game is over
+func (s *Server) moves() []int {
+	if s.over {
+		return nil
+	}
+	return s.pos.ValidMoves()
+}
+
+// play a move leaving the position from the side to move
+func (s *Server) play(hole int) (game.MoveResult, error) {
+	if s.over {
+		return game.BadMove, errors.New("move: game is over")
+	}
+	t, err := s.pos.Play(hole)
+	if err != nil {
+		return game.BadMove, fmt.Errorf("move %d: %v", hole, err)
+	}
+	switch t.Result {
+	case game.EndOfTurn:
+		s.pos = t.Next.ChangePlayer()
+	case game.EndOfGame:
+		s.pos, s.over = t.Next, true
+	default:
+		s.pos = t.Next
+	}
+	return t.Result, nil
+}
+
+// bestMove asks the player for a move, within movetime if given
+func (s *Server) bestMove(c Command) error {
+	if s.over {
+		return errors.New("go: game is over")
+	}
+	ctx := context.Background()
+	movetime, ok, err := c.IntValue("movetime")
+	if err != nil {
+		return err
+	}
+	if ok {
+		// the player aims to finish in most of the time
+		d := time.Duration(movetime) * time.Millisecond
+		ctx = game.WithClock(ctx, game.Clock{MoveTime: d * 9 / 10})
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	hole, err := s.agent.Move(ctx, s.pos)
+	if errors.Is(err, game.ErrResign) {
+		s.respond("%s %s", RespBestMove, Resign)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("go: %v", err)
+	}
+	s.respond("%s %d", RespBestMove, hole)
+	return nil
+}
+
+// joinInts formats values separated by spaces
+func joinInts(vals []int) string {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,83 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	// register the minimax player type
+	_ "github.com/EFX-PXT1/mancala-go/pkg/search"
+	"github.com/stretchr/testify/assert"
+)
+
+// testConf seeds the random player
+func testConf(playerType string, options []string) (map[string]string, error) {
+	conf := map[string]string{"type": playerType, "quiet": "true", "seed": "1"}
+	for _, o := range options {
+		kv := strings.SplitN(o, "=", 2)
+		conf[kv[0]] = kv[1]
+	}
+	return conf, nil
+}
+
+func serve(t *testing.T, script string) string {
+	game.DefineGame(6, 4)
+	s, err := NewServer("test", testConf, "random", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err = s.Serve(strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestServer(t *testing.T) {
+	assert := assert.New(t)
+
+	script := `mancala 1
+rules width 3 stones 2
+isready
+moves
+move 3
+move 3
+move 4
+move 3
+show
+position startpos moves 3 1
+moves
+go
+position 0,1,0,0,5,3,0,0
+move 1
+moves
+go
+bogus
+quit
+moves
+`
+	expected := `id name test
+mancalaok
+readyok
+moves 1 2 3
+moved 3 EndOfTurn 0,2,2,2,0,3,3,0
+moved 3 EndOfTurn 0,3,3,0,0,3,3,0
+error move 4: hole not in range
+error move 3: invalid move
+position 0,3,3,0,0,3,3,0
+moves 1 2 3
+bestmove 3
+moved 1 EndOfGame 1,0,0,0,5,3,0,0
+moves
+error go: game is over
+`
+	assert.Equal(expected, serve(t, script))
+
+	// the same commands give the same responses
+	script = "player random seed=7\ngo\ngo\ngo\n"
+	assert.Equal(serve(t, script), serve(t, script))
+	script = "player minimax depth=4 seed=7\ngo\nmove 3\ngo\n"
+	out := serve(t, script)
+	assert.Equal(2, strings.Count(out, "bestmove "))
+	assert.Equal(out, serve(t, script))
+}
@@ -0,0 +1,115 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+// Mistake and Blunder mark moves losing at least the threshold,
+// and twice the threshold
+const (
+	Mistake = "?"
+	Blunder = "??"
+)
+
+// Note is the analysis of a move
+type Note struct {
+	// Ply is the index of the move
+	Ply  int
+	Side int
+	Hole int
+	// Score is that of the move played, from the side moving
+	Score float64
+	// Best is the best move and its line
+	Best search.Line
+	// Loss is the score given up against the best move
+	Loss float64
+	// Accuracy is 100 for the best move and 0 for the worst
+	Accuracy float64
+	// Forced is set when there was only one move
+	Forced bool
+	Mark   string
+}
+
+// Report is the annotation of a game
+type Report struct {
+	Notes []Note
+	// Accuracy is the mean accuracy of the moves of each side that were not forced
+	Accuracy [2]float64
+	Mistakes [2]int
+	Blunders [2]int
+}
+
+// Annotate analyses every move of a record with m, commenting on each
+// losing at least threshold with the better move and its line, and
+// tagging the accuracy of each side as p1accuracy and p2accuracy.
+// The comments of an earlier annotation are replaced, others kept.
+func Annotate(ctx context.Context, r *Record, m *search.Minimax, threshold float64) (*Report, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold %g must be above 0", threshold)
+	}
+	plies, err := r.Replay()
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range r.Comments {
+		if annotation(c) {
+			delete(r.Comments, i)
+		}
+	}
+	report := &Report{}
+	var total [2]float64
+	var counted [2]int
+	for i, p := range plies {
+		lines, err := m.Analyse(ctx, p.Pos, 0)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		n := Note{Ply: i, Side: p.Side, Hole: p.Hole, Best: lines[0], Accuracy: 100, Forced: len(lines) == 1}
+		worst := lines[len(lines)-1].Score
+		for _, l := range lines {
+			if l.Move == p.Hole {
+				n.Score = l.Score
+			}
+		}
+		n.Loss = n.Best.Score - n.Score
+		if n.Best.Score > worst {
+			n.Accuracy = 100 * (n.Score - worst) / (n.Best.Score - worst)
+		}
+		switch {
+		case n.Loss >= 2*threshold:
+			n.Mark = Blunder
+			report.Blunders[p.Side]++
+		case n.Loss >= threshold:
+			n.Mark = Mistake
+			report.Mistakes[p.Side]++
+		}
+		if n.Mark != "" {
+			r.Comments[i] = fmt.Sprintf("%s"+lossComment+"%g, better %d %+g: %s", n.Mark, n.Loss, n.Best.Move, n.Best.Score, n.Best.Notation)
+		}
+		if !n.Forced {
+			total[p.Side] += n.Accuracy
+			counted[p.Side]++
+		}
+		report.Notes = append(report.Notes, n)
+	}
+	for side := range total {
+		report.Accuracy[side] = 100
+		if counted[side] > 0 {
+			report.Accuracy[side] = total[side] / float64(counted[side])
+		}
+		r.Tags[fmt.Sprintf("p%daccuracy", side+1)] = fmt.Sprintf("%.1f", report.Accuracy[side])
+	}
+	return report, nil
+}
+
+// lossComment follows the mark in the comment of a marked move
+const lossComment = " loses "
+
+// annotation reports if a comment was written by Annotate
+func annotation(comment string) bool {
+	return strings.HasPrefix(comment, Mistake+lossComment) || strings.HasPrefix(comment, Blunder+lossComment)
+}
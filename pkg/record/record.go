@@ -0,0 +1,253 @@
+// Package record reads and writes game records with comments
+package record
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Record is a game from the start, tags describing it and comments
+// following its moves. As text it is written
+//
+//	[width 6]
+//	[stones 4]
+//	[p1 minimax]
+//	4+1 {a comment on 1} 3 2
+//
+// the moves being in the game notation, and a file can hold many.
+type Record struct {
+	Width, Stones int
+	// Tags are the names and values of the game, such as p1 and p2
+	Tags map[string]string
+	// Moves are the holes played in turn
+	Moves []int
+	// Comments follow the move of their index
+	Comments map[int]string
+}
+
+// New creates an empty record of a game
+func New(width int, stones int) *Record {
+	return &Record{Width: width, Stones: stones, Tags: make(map[string]string), Comments: make(map[int]string)}
+}
+
+// Start is the start position of the game, whatever the current game
+func (r *Record) Start() *game.Position {
+	p := &game.Position{}
+	for i := range p.Row {
+		p.Row[i].Items = make([]int, r.Width+1)
+		for h := 1; h <= r.Width; h++ {
+			p.Row[i].Items[h] = r.Stones
+		}
+	}
+	return p
+}
+
+// Ply is a move of the game
+type Ply struct {
+	// Pos is the position moved from, from the side to move
+	Pos *game.Position
+	// Side is 0 for the first player
+	Side int
+	Hole int
+	// Transition is the result of the move
+	Transition *game.Transition
+}
+
+// Replay plays the moves from the start
+func (r *Record) Replay() ([]Ply, error) {
+	pos, side := r.Start(), 0
+	plies := make([]Ply, 0, len(r.Moves))
+	for i, hole := range r.Moves {
+		if pos.IsGameEnd() {
+			return nil, fmt.Errorf("move %d of %d after the end of the game", i+1, hole)
+		}
+		t, err := pos.Play(hole)
+		if err != nil {
+			return nil, fmt.Errorf("move %d of %d from %s: %v", i+1, hole, pos.AsCsv(), err)
+		}
+		plies = append(plies, Ply{Pos: pos, Side: side, Hole: hole, Transition: t})
+		pos = t.Next
+		if t.Result == game.EndOfTurn {
+			pos, side = pos.ChangePlayer(), 1-side
+		}
+	}
+	return plies, nil
+}
+
+// Write the record as text, width and stones then the other tags
+// in name order, followed by the moves
+func (r *Record) Write(w io.Writer) error {
+	plies, err := r.Replay()
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "[width %d]\n[stones %d]\n", r.Width, r.Stones)
+	names := make([]string, 0, len(r.Tags))
+	for name := range r.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "[%s %s]\n", name, r.Tags[name])
+	}
+
+	var words []string
+	var turn strings.Builder
+	for i, p := range plies {
+		turn.WriteString(strconv.Itoa(p.Hole))
+		if p.Transition.Result == game.RepeatTurn {
+			turn.WriteString("+")
+		}
+		c, ok := r.Comments[i]
+		if p.Transition.Result != game.RepeatTurn || ok || i == len(plies)-1 {
+			words = append(words, turn.String())
+			turn.Reset()
+		}
+		if ok {
+			words = append(words, "{"+c+"}")
+		}
+	}
+	fmt.Fprintf(b, "%s\n", strings.Join(words, " "))
+	return b.Flush()
+}
+
+// Read the records of a text, a tag after any moves starting the next
+func Read(rd io.Reader) ([]*Record, error) {
+	var records []*Record
+	var r *Record
+	var moves strings.Builder
+	// finish parses the moves of the current record
+	finish := func() error {
+		if r == nil {
+			return nil
+		}
+		if err := r.parseMoves(moves.String()); err != nil {
+			return fmt.Errorf("record %d: %v", len(records)+1, err)
+		}
+		if _, err := r.Replay(); err != nil {
+			return fmt.Errorf("record %d: %v", len(records)+1, err)
+		}
+		records = append(records, r)
+		r = nil
+		moves.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(rd)
+	inComment := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !inComment && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			if r != nil && strings.TrimSpace(moves.String()) != "" {
+				if err := finish(); err != nil {
+					return nil, err
+				}
+			}
+			if r == nil {
+				r = New(0, 0)
+			}
+			if err := r.tag(line[1 : len(line)-1]); err != nil {
+				return nil, fmt.Errorf("record %d: %v", len(records)+1, err)
+			}
+			continue
+		}
+		if line != "" && r == nil {
+			return nil, fmt.Errorf("record %d: moves before the tags", len(records)+1)
+		}
+		moves.WriteString(line)
+		moves.WriteString("\n")
+		inComment = strings.LastIndex(line, "{") > strings.LastIndex(line, "}")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// tag sets a tag, width and stones being the game dimensions
+func (r *Record) tag(s string) error {
+	fields := strings.SplitN(strings.TrimSpace(s), " ", 2)
+	name, value := fields[0], ""
+	if len(fields) == 2 {
+		value = strings.TrimSpace(fields[1])
+	}
+	switch name {
+	case "width", "stones":
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+		if name == "width" {
+			r.Width = v
+		} else {
+			r.Stones = v
+		}
+	case "":
+		return errors.New("empty tag")
+	default:
+		r.Tags[name] = value
+	}
+	return nil
+}
+
+// parseMoves reads moves in the game notation, each comment
+// following the move before it
+func (r *Record) parseMoves(s string) error {
+	if r.Width == 0 || r.Stones == 0 {
+		return errors.New("width and stones tags are required")
+	}
+	for len(s) > 0 {
+		open := strings.Index(s, "{")
+		if open < 0 {
+			open = len(s)
+		}
+		holes, err := game.ParseLine(s[:open])
+		if err != nil {
+			return err
+		}
+		r.Moves = append(r.Moves, holes...)
+		if open == len(s) {
+			break
+		}
+		end := strings.Index(s[open:], "}")
+		if end < 0 {
+			return errors.New("comment not closed")
+		}
+		if len(r.Moves) == 0 {
+			return errors.New("comment before the first move")
+		}
+		r.Comments[len(r.Moves)-1] = strings.Join(strings.Fields(s[open+1:open+end]), " ")
+		s = s[open+end+1:]
+	}
+	return nil
+}
+
+// SetResult tags the outcome of a game, from the side of the first player
+func (r *Record) SetResult(result *game.GameResult) {
+	switch result.Winner {
+	case 0:
+		r.Tags["result"] = "1-0"
+	case 1:
+		r.Tags["result"] = "0-1"
+	default:
+		r.Tags["result"] = "1/2-1/2"
+	}
+	r.Tags["score"] = fmt.Sprintf("%+d", result.Score)
+	switch {
+	case result.Resigned:
+		r.Tags["termination"] = "resigned"
+	case result.TimeOut:
+		r.Tags["termination"] = "time"
+	}
+}
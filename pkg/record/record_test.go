@@ -0,0 +1,105 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(6, 4)
+	r.Tags["p1"] = "minimax"
+	r.Tags["p2"] = "console"
+	r.Moves = []int{4, 3, 1, 2}
+	r.Comments[0] = "a repeat turn"
+	r.Comments[2] = "reply"
+
+	var b bytes.Buffer
+	assert.Nil(r.Write(&b))
+	text := "[width 6]\n[stones 4]\n[p1 minimax]\n[p2 console]\n4+ {a repeat turn} 3 1 {reply} 2\n"
+	assert.Equal(text, b.String())
+
+	// a second record follows the moves of the first
+	records, err := Read(strings.NewReader(text + "\n[width 3]\n[stones 1]\n1 2+\n1\n2 {split\nover lines}\n"))
+	assert.Nil(err)
+	assert.Len(records, 2)
+	assert.Equal(r, records[0])
+	assert.Equal([]int{1, 2, 1, 2}, records[1].Moves)
+	assert.Equal("split over lines", records[1].Comments[3])
+
+	plies, err := records[1].Replay()
+	assert.Nil(err)
+	assert.Len(plies, 4)
+	assert.Equal(game.EndOfGame, plies[3].Transition.Result)
+
+	for text, want := range map[string]string{
+		"1 2":                        "record 1: moves before the tags",
+		"[width 6]\n1 2":             "record 1: width and stones tags are required",
+		"[width x]":                  `record 1: invalid width "x"`,
+		"[width 3]\n[stones 1]\n4":   "record 1: move 1 of 4 from 0,1,1,1,0,1,1,1: hole not in range",
+		"[width 3]\n[stones 1]\n1 {": "record 1: comment not closed",
+	} {
+		_, err := Read(strings.NewReader(text))
+		assert.EqualError(err, want, text)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	// minimax plays random, the annotator seeing as far as minimax
+	random, err := game.CreateAgent(map[string]string{"type": "random", "seed": "1", "quiet": "true"})
+	assert.Nil(err)
+	agents := [2]game.Agent{&search.Minimax{Depth: 3, Eval: game.EvaluatorFunc(game.StoreEvaluator)}, random}
+	r := New(4, 3)
+	_, err = game.PlayGame(context.Background(), agents, r.Start(),
+		func(side int, pos *game.Position, hole int, t *game.Transition) {
+			r.Moves = append(r.Moves, hole)
+		})
+	assert.Nil(err)
+
+	m := &search.Minimax{Depth: 3, Eval: game.EvaluatorFunc(game.StoreEvaluator)}
+	report, err := Annotate(context.Background(), r, m, 2)
+	assert.Nil(err)
+	assert.Len(report.Notes, len(r.Moves))
+	assert.Equal(100.0, report.Accuracy[0])
+	assert.Equal(0, report.Mistakes[0]+report.Blunders[0])
+	assert.True(report.Accuracy[1] < 100)
+	assert.True(report.Mistakes[1]+report.Blunders[1] > 0)
+	assert.Equal("100.0", r.Tags["p1accuracy"])
+
+	for _, n := range report.Notes {
+		if n.Side == 0 {
+			assert.Equal(0.0, n.Loss)
+		}
+		if n.Mark != "" {
+			assert.Contains(r.Comments[n.Ply], n.Mark+" loses")
+		}
+	}
+
+	// the annotated record reads back with its comments
+	var b bytes.Buffer
+	assert.Nil(r.Write(&b))
+	records, err := Read(&b)
+	assert.Nil(err)
+	assert.Equal(r, records[0])
+
+	// annotating again replaces the earlier comments, keeping others
+	last := len(r.Moves) - 1
+	r.Comments[last] = "a fine finish"
+	assert.True(len(r.Comments) > 1)
+	_, err = Annotate(context.Background(), r, m, 100)
+	assert.Nil(err)
+	assert.Equal(map[int]string{last: "a fine finish"}, r.Comments)
+
+	_, err = Annotate(context.Background(), r, m, 0)
+	assert.EqualError(err, "threshold 0 must be above 0")
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"sort"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Line is an analysed move with its score and principal variation
+type Line struct {
+	Move int `json:"move"`
+	// Score is from the side to move
+	Score float64 `json:"score"`
+	// PV are the best moves of both sides, starting with Move
+	PV []int `json:"pv"`
+	// Notation is the PV in the game notation
+	Notation string `json:"notation"`
+}
+
+// Analyse scores every move of a position to the depth, best first
+// and the lowest hole on a tie, each with its principal variation.
+// Every move is searched with a full window so all the scores are exact.
+// multipv limits the lines returned, zero for all.
+func (m *Minimax) Analyse(ctx context.Context, pos *game.Position, multipv int) ([]Line, error) {
+	if pos.IsGameEnd() {
+		return nil, ErrGameOver
+	}
+	s := &searcher{m: m, ctx: ctx}
+	lines := s.scores(pos, m.Depth)
+	if lines == nil {
+		return nil, ctx.Err()
+	}
+	if multipv > 0 && multipv < len(lines) {
+		lines = lines[:multipv]
+	}
+	for i := range lines {
+		lines[i].PV = s.line(pos, lines[i].Move, m.Depth-1)
+		if s.stopped {
+			return nil, ctx.Err()
+		}
+		notation, err := game.FormatLine(pos, lines[i].PV)
+		if err != nil {
+			return nil, err
+		}
+		lines[i].Notation = notation
+	}
+	m.Nodes, m.Reached = s.nodes, m.Depth
+	return lines, nil
+}
+
+// scores searches every move with a full window, best first
+// and the lowest hole on a tie, nil if stopped
+func (s *searcher) scores(pos *game.Position, depth int) []Line {
+	var lines []Line
+	for _, hole := range pos.ValidMoves() {
+		score := s.child(pos, 0, hole, depth-1, -infinity, infinity)
+		if s.stopped || s.ctx.Err() != nil {
+			return nil
+		}
+		lines = append(lines, Line{Move: hole, Score: score})
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Score > lines[j].Score
+	})
+	return lines
+}
+
+// line follows a move by the best move of each position reached,
+// searched to the depth remaining
+func (s *searcher) line(pos *game.Position, hole int, depth int) []int {
+	line := []int{hole}
+	for {
+		t, _ := pos.Play(hole)
+		if t.Result == game.EndOfGame || depth <= 0 {
+			return line
+		}
+		pos = t.Next
+		if t.Result == game.EndOfTurn {
+			pos = pos.ChangePlayer()
+		}
+		hole, _ = s.root(pos, depth, 0)
+		if s.stopped {
+			return line
+		}
+		line = append(line, hole)
+		depth--
+	}
+}
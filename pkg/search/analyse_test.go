@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyse(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	pos := game.StartPosition()
+	m := &Minimax{Depth: 5, Eval: game.EvaluatorFunc(game.StoreEvaluator)}
+	lines, err := m.Analyse(context.Background(), pos, 0)
+	assert.Nil(err)
+	assert.Len(lines, len(pos.ValidMoves()))
+
+	// the best line agrees with the search
+	best, score, err := m.Search(context.Background(), pos)
+	assert.Nil(err)
+	assert.Equal(best, lines[0].Move)
+	assert.Equal(score, lines[0].Score)
+	for i, l := range lines {
+		if i > 0 {
+			assert.True(l.Score <= lines[i-1].Score)
+		}
+		// each move is scored exactly
+		alone := &Minimax{Depth: 4, Eval: m.Eval}
+		t, _ := pos.Play(l.Move)
+		next := t.Next
+		want := 0.0
+		switch t.Result {
+		case game.EndOfGame:
+			want = float64(next.Score())
+		case game.RepeatTurn:
+			_, want, _ = alone.Search(context.Background(), next)
+		default:
+			_, want, _ = alone.Search(context.Background(), next.ChangePlayer())
+			want = -want
+		}
+		assert.Equal(want, l.Score, "move %d", l.Move)
+		assert.Equal(l.Move, l.PV[0])
+		assert.Len(l.PV, 5)
+		holes, err := game.ParseLine(l.Notation)
+		assert.Nil(err)
+		assert.Equal(l.PV, holes)
+	}
+
+	lines, err = m.Analyse(context.Background(), pos, 2)
+	assert.Nil(err)
+	assert.Len(lines, 2)
+}
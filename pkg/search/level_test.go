@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoisyEvaluator(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	e := &NoisyEvaluator{Evaluator: game.EvaluatorFunc(game.StoreEvaluator), Noise: 2, Seed: 1}
+	pos := game.StartPosition()
+	assert.Equal(e.Evaluate(pos), e.Evaluate(pos))
+	assert.NotEqual(e.Evaluate(pos), (&NoisyEvaluator{Evaluator: e.Evaluator, Noise: 2, Seed: 2}).Evaluate(pos))
+
+	// the noise of many positions has the deviation asked for
+	sum, squares, n := 0.0, 0.0, 10000
+	for i := 0; i < n; i++ {
+		e.Seed = uint64(i)
+		x := e.Evaluate(pos)
+		sum += x
+		squares += x * x
+	}
+	mean := sum / float64(n)
+	assert.InDelta(0, mean, 0.1)
+	assert.InDelta(2, math.Sqrt(squares/float64(n)-mean*mean), 0.1)
+}
+
+func TestMinimaxLevel(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	a, err := game.CreateAgent(map[string]string{"type": "minimax", "level": "3", "seed": "5"})
+	assert.Nil(err)
+	m := a.(*Minimax)
+	assert.Equal(Levels[2].Depth, m.Depth)
+	assert.Equal(Levels[2].Temperature, m.Temperature)
+	assert.IsType(&NoisyEvaluator{}, m.Eval)
+
+	// seeded levels repeat their choices, which vary
+	b, _ := game.CreateAgent(map[string]string{"type": "minimax", "level": "3", "seed": "5"})
+	pos := game.StartPosition()
+	chosen := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		x, err := a.Move(context.Background(), pos)
+		assert.Nil(err)
+		y, _ := b.Move(context.Background(), pos)
+		assert.Equal(x, y)
+		chosen[x] = true
+	}
+	assert.True(len(chosen) > 1)
+
+	// the top level always plays its best move
+	top, _ := game.CreateAgent(map[string]string{"type": "minimax", "level": "10"})
+	m = top.(*Minimax)
+	assert.Equal(0.0, m.Temperature)
+	assert.Equal(game.EvaluatorFunc(game.StoreEvaluator).Evaluate(pos), m.Eval.Evaluate(pos))
+
+	_, err = game.CreateAgent(map[string]string{"type": "minimax", "level": "11"})
+	assert.EqualError(err, "player type minimax: option level: 11 is out of range 0..10")
+}
+
+func TestPick(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	// a high temperature plays every move, a low one only the best
+	m := &Minimax{Depth: 2, Eval: game.EvaluatorFunc(game.StoreEvaluator), Temperature: 1000}
+	m.rnd = rand.New(rand.NewSource(1))
+	pos := game.StartPosition()
+	counts := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		hole, _, err := m.pick(context.Background(), pos)
+		assert.Nil(err)
+		counts[hole]++
+	}
+	assert.Len(counts, len(pos.ValidMoves()))
+
+	m.Temperature = 1e-9
+	best, _, _ := m.Search(context.Background(), pos)
+	for i := 0; i < 10; i++ {
+		hole, _, _ := m.pick(context.Background(), pos)
+		assert.Equal(best, hole)
+	}
+
+	// a struct literal without a source uses the global one
+	m = &Minimax{Depth: 2, Eval: game.EvaluatorFunc(game.StoreEvaluator), Temperature: 1}
+	hole, err := m.Move(context.Background(), pos)
+	assert.Nil(err)
+	assert.Contains(pos.ValidMoves(), hole)
+}
+
+// BenchmarkLevels plays random against level 1 and then each level
+// against the next, reporting the score of the weaker
+func BenchmarkLevels(b *testing.B) {
+	game.DefineGame(6, 4)
+	confs := []map[string]string{{"type": "random", "seed": "1", "quiet": "true"}}
+	names := []string{"random"}
+	for level := 1; level <= len(Levels); level++ {
+		confs = append(confs, map[string]string{"type": "minimax", "level": fmt.Sprint(level), "seed": fmt.Sprint(level + 1)})
+		names = append(names, fmt.Sprint(level))
+	}
+	for i := 0; i+1 < len(confs); i++ {
+		b.Run(names[i]+"-vs-"+names[i+1], func(b *testing.B) {
+			var agents [2]game.Agent
+			for side := range agents {
+				a, err := game.CreateAgent(confs[i+side])
+				if err != nil {
+					b.Fatal(err)
+				}
+				agents[side] = a
+			}
+			score := 0.0
+			for n := 0; n < b.N; n++ {
+				// alternate who moves first
+				first := n % 2
+				result, err := game.PlayGame(context.Background(),
+					[2]game.Agent{agents[first], agents[1-first]}, game.StartPosition(), nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				switch result.Winner {
+				case -1:
+					score += 0.5
+				case first:
+					score++
+				}
+			}
+			b.ReportMetric(score/float64(b.N), "score")
+		})
+	}
+}
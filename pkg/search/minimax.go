@@ -0,0 +1,371 @@
+// Package search chooses moves by looking ahead
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/book"
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// infinity bounds every score
+var infinity = math.Inf(1)
+
+// maxDepth limits iterative deepening
+const maxDepth = 64
+
+// ErrGameOver is returned for a position with no move to search
+var ErrGameOver = errors.New("game is over")
+
+// Minimax searches to a fixed depth with alpha beta pruning,
+// a repeat turn counting as a ply for the same side.
+// Positions at the depth are scored by Eval, the end of
+// the game by the store margin. Given a clock or move time
+// it deepens iteratively until its budget is spent instead.
+type Minimax struct {
+	Depth int
+	Eval  game.Evaluator
+	// MoveTime is the budget for a move without a clock, zero for none
+	MoveTime time.Duration
+	// Table, if not nil, keeps results across transpositions and moves
+	Table *Table
+	// Threads search in parallel sharing the Table, Lazy SMP
+	Threads int
+	// Verbose shows the score and search statistics of each move
+	Verbose bool
+	// Output receives the Verbose lines, stderr when nil, keeping
+	// them off the stdout of the engine protocol
+	Output io.Writer
+	// Temperature, when above zero, chooses moves at random by score
+	// at Depth, ignoring any clock or MoveTime, see Level
+	Temperature float64
+	// Book, if not nil, chooses the moves of the positions it has
+	Book *book.Book
+	// Nodes are the positions searched by the last move
+	Nodes uint64
+	// Reached is the depth completed by the last move
+	Reached int
+
+	rnd *rand.Rand
+}
+
+func newMinimax(opts game.Options) (game.Agent, error) {
+	eval, err := game.LookupEvaluator(opts.String("evaluator"))
+	if err != nil {
+		return nil, err
+	}
+	m := &Minimax{
+		Depth:    opts.Int("depth"),
+		Eval:     eval,
+		MoveTime: time.Duration(opts.Int("movetime")) * time.Millisecond,
+		Threads:  opts.Int("threads"),
+		Verbose:  opts.Bool("verbose"),
+	}
+	seed := int64(opts.Int("seed"))
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	m.rnd = rand.New(rand.NewSource(seed))
+	if level := opts.Int("level"); level > 0 {
+		l := Levels[level-1]
+		m.Depth, m.MoveTime, m.Temperature = l.Depth, 0, l.Temperature
+		if l.Noise > 0 {
+			m.Eval = &NoisyEvaluator{Evaluator: eval, Noise: l.Noise, Seed: uint64(seed)}
+		}
+	}
+	if path := opts.String("book"); path != "" {
+		if m.Book, err = book.Open(path); err != nil {
+			return nil, err
+		}
+	}
+	if mb := opts.Int("hash"); mb > 0 {
+		m.Table = NewTable(mb)
+	} else if m.Threads > 1 {
+		return nil, fmt.Errorf("threads %d needs a hash table to share", m.Threads)
+	}
+	return m, nil
+}
+
+// Move chooses a book move, else the best move, the lowest hole
+// on a tie, or one at random by score given a Temperature.
+// With a Temperature it searches to Depth whatever the budget.
+func (m *Minimax) Move(ctx context.Context, pos *game.Position) (int, error) {
+	if m.Book != nil {
+		if hole, ok := m.Book.Choose(pos, m.rnd); ok {
+			m.Nodes, m.Reached = 0, 0
+			if m.Verbose {
+				fmt.Fprintf(m.output(), "minimax > %d book\n", hole)
+			}
+			return hole, nil
+		}
+	}
+	var before TableStats
+	if m.Table != nil {
+		before = m.Table.Stats()
+	}
+	budget := m.MoveTime
+	if c, ok := game.ClockFrom(ctx); ok {
+		budget = c.Budget()
+	}
+	var best int
+	var score float64
+	var err error
+	switch {
+	case m.Temperature > 0:
+		best, score, err = m.pick(ctx, pos)
+	case budget > 0:
+		best, score, err = m.Deepen(ctx, pos, budget)
+	default:
+		best, score, err = m.Search(ctx, pos)
+	}
+	if err == nil && m.Verbose {
+		w := m.output()
+		fmt.Fprintf(w, "minimax > %d score %+g depth %d nodes %d", best, score, m.Reached, m.Nodes)
+		if m.Table != nil {
+			after := m.Table.Stats()
+			fmt.Fprintf(w, " %s", TableStats{
+				Probes: after.Probes - before.Probes,
+				Hits:   after.Hits - before.Hits,
+				Stores: after.Stores - before.Stores,
+			})
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	return best, err
+}
+
+// output is where the Verbose lines go
+func (m *Minimax) output() io.Writer {
+	if m.Output != nil {
+		return m.Output
+	}
+	return os.Stderr
+}
+
+// Search finds the best move and its score for the side to move,
+// ErrGameOver if the game is over
+func (m *Minimax) Search(ctx context.Context, pos *game.Position) (int, float64, error) {
+	if pos.IsGameEnd() {
+		return 0, 0, ErrGameOver
+	}
+	stop := m.help(ctx, pos, m.Depth)
+	s := &searcher{m: m, ctx: ctx}
+	best, score := s.root(pos, m.Depth, 0)
+	m.Nodes, m.Reached = s.nodes+stop(), m.Depth
+	if s.stopped {
+		return 0, 0, ctx.Err()
+	}
+	return best, score, nil
+}
+
+// Deepen searches one ply deeper at a time until the budget is spent,
+// returning the best move of the last depth completed. Only a context
+// done before the first depth completes, or a game over, is an error.
+func (m *Minimax) Deepen(ctx context.Context, pos *game.Position, budget time.Duration) (int, float64, error) {
+	if pos.IsGameEnd() {
+		return 0, 0, ErrGameOver
+	}
+	tctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	stop := m.help(tctx, pos, maxDepth)
+	s := &searcher{m: m, ctx: tctx}
+	best, score := 0, 0.0
+	m.Reached = 0
+	for depth := 1; depth <= maxDepth; depth++ {
+		b, sc := s.root(pos, depth, best)
+		if s.stopped {
+			break
+		}
+		best, score, m.Reached = b, sc, depth
+	}
+	m.Nodes = s.nodes + stop()
+	if m.Reached == 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		// not even a ply in time, any move beats none
+		best = pos.ValidMoves()[0]
+	}
+	return best, score, nil
+}
+
+// help starts the helper threads of a Lazy SMP search, each deepening
+// up to depth from a different first move, so filling the shared table
+// for the main search. stop ends them, returning the nodes they searched.
+func (m *Minimax) help(ctx context.Context, pos *game.Position, depth int) (stop func() uint64) {
+	if m.Threads <= 1 || m.Table == nil {
+		return func() uint64 { return 0 }
+	}
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		return func() uint64 { return 0 }
+	}
+	hctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var nodes uint64
+	for i := 1; i < m.Threads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := &searcher{m: m, ctx: hctx}
+			hole := moves[i%len(moves)]
+			// odd helpers start a ply ahead
+			for d := 1 + i%2; d <= depth; d++ {
+				b, _ := s.root(pos, d, hole)
+				if s.stopped {
+					break
+				}
+				hole = b
+			}
+			atomic.AddUint64(&nodes, s.nodes)
+		}(i)
+	}
+	return func() uint64 {
+		cancel()
+		wg.Wait()
+		return nodes
+	}
+}
+
+// searcher is the state of a single search
+type searcher struct {
+	m       *Minimax
+	ctx     context.Context
+	nodes   uint64
+	stopped bool
+}
+
+// root searches the moves of a position to a depth,
+// hole, if valid, being searched first
+func (s *searcher) root(pos *game.Position, depth int, hole int) (int, float64) {
+	s.nodes++
+	best, alpha := 0, -infinity
+	for _, h := range first(pos.ValidMoves(), hole) {
+		if s.ctx.Err() != nil {
+			s.stopped = true
+			return 0, 0
+		}
+		score := s.child(pos, 0, h, depth-1, alpha, infinity)
+		if s.stopped {
+			return 0, 0
+		}
+		if score > alpha {
+			best, alpha = h, score
+		}
+	}
+	return best, alpha
+}
+
+// child scores a move from the side of the player making it
+func (s *searcher) child(pos *game.Position, side int, hole int, depth int, alpha float64, beta float64) float64 {
+	t, _ := pos.Play(hole)
+	switch t.Result {
+	case game.EndOfGame:
+		return float64(t.Next.Score())
+	case game.RepeatTurn:
+		return s.negamax(t.Next, side, depth, alpha, beta)
+	}
+	return -s.negamax(t.Next.ChangePlayer(), 1-side, depth, -beta, -alpha)
+}
+
+// negamax scores a position from the side to move
+func (s *searcher) negamax(pos *game.Position, side int, depth int, alpha float64, beta float64) float64 {
+	s.nodes++
+	if s.nodes%1024 == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+	if depth <= 0 {
+		return s.m.Eval.Evaluate(pos)
+	}
+
+	table := s.m.Table
+	moves := pos.ValidMoves()
+	var key uint64
+	if table != nil {
+		key = Key(pos, side)
+		if e, ok := table.Probe(key); ok {
+			if int(e.Depth) >= depth {
+				switch {
+				case e.Bound == Exact,
+					e.Bound == Lower && e.Score >= beta,
+					e.Bound == Upper && e.Score <= alpha:
+					return e.Score
+				}
+			}
+			moves = first(moves, int(e.Move))
+		}
+	}
+
+	start, best, bestMove := alpha, -infinity, 0
+	for _, hole := range moves {
+		score := s.child(pos, side, hole, depth-1, alpha, beta)
+		if s.stopped {
+			return 0
+		}
+		if score > best {
+			best, bestMove = score, hole
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+
+	if table != nil {
+		e := Entry{Key: key, Score: best, Move: int32(bestMove), Depth: int16(depth), Bound: Exact}
+		switch {
+		case best <= start:
+			e.Bound = Upper
+		case best >= beta:
+			e.Bound = Lower
+		}
+		table.Store(e)
+	}
+	return best
+}
+
+// first orders a move to be searched first
+func first(moves []int, hole int) []int {
+	for i, h := range moves {
+		if h == hole {
+			ordered := make([]int, 0, len(moves))
+			ordered = append(ordered, hole)
+			ordered = append(ordered, moves[:i]...)
+			return append(ordered, moves[i+1:]...)
+		}
+	}
+	return moves
+}
+
+func init() {
+	game.RegisterPlayerType(game.PlayerType{
+		Name:        "minimax",
+		Description: "searches ahead for the best score of its evaluator",
+		Params: []game.Param{
+			{Name: "depth", Type: game.IntParam, Default: "6", Min: 1, Max: 16, Description: "plies to search without a clock or move time"},
+			{Name: "movetime", Type: game.IntParam, Default: "0", Min: 0, Max: 3600000, Description: "milliseconds to search each move without a clock, 0 for a fixed depth"},
+			{Name: "evaluator", Type: game.StringParam, Default: "store", Description: "evaluator scoring positions at the depth, see players"},
+			{Name: "hash", Type: game.IntParam, Default: "16", Min: 0, Max: 4096, Description: "megabytes of transposition table, 0 for none"},
+			{Name: "threads", Type: game.IntParam, Default: "1", Min: 1, Max: 256, Description: "threads searching in parallel, sharing the hash table"},
+			{Name: "verbose", Type: game.BoolParam, Default: "false", Description: "show the score and search statistics of each move on stderr"},
+			{Name: "level", Type: game.IntParam, Default: "0", Min: 0, Max: 10, Description: "strength from 1 to 10 setting the depth, with noise and weaker moves below 10, 0 for none"},
+			{Name: "book", Type: game.StringParam, Description: "directory of an opening book to play from first, see book"},
+			{Name: "seed", Type: game.IntParam, Default: "0", Description: "random seed of a level or book, 0 is time based"},
+		},
+		Agent: newMinimax,
+	})
+}
@@ -0,0 +1,230 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/book"
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+// plain is minimax without pruning
+func plain(pos *game.Position, depth int) float64 {
+	if depth <= 0 {
+		return float64(pos.Score())
+	}
+	best := -infinity
+	for _, hole := range pos.ValidMoves() {
+		t, _ := pos.Play(hole)
+		var score float64
+		switch t.Result {
+		case game.EndOfGame:
+			score = float64(t.Next.Score())
+		case game.RepeatTurn:
+			score = plain(t.Next, depth-1)
+		default:
+			score = -plain(t.Next.ChangePlayer(), depth-1)
+		}
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func TestMinimaxPruning(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+	rnd := rand.New(rand.NewSource(1))
+
+	// compare along random games
+	for g := 0; g < 5; g++ {
+		pos := game.StartPosition()
+		for !pos.IsGameEnd() {
+			for depth := 1; depth <= 5; depth++ {
+				m := &Minimax{Depth: depth, Eval: game.EvaluatorFunc(game.StoreEvaluator)}
+				_, score, err := m.Search(context.Background(), pos)
+				assert.Nil(err)
+				assert.Equal(plain(pos, depth), score, "%s depth %d", pos.AsCsv(), depth)
+			}
+			moves := pos.ValidMoves()
+			t, _ := pos.Play(moves[rnd.Intn(len(moves))])
+			pos = t.Next
+			if t.Result == game.EndOfTurn {
+				pos = pos.ChangePlayer()
+			}
+		}
+	}
+}
+
+func TestMinimaxSteal(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 4)
+
+	// 2 lands in the empty hole 1 capturing 8
+	pos := game.CreatePosition(0, 0, 1, 3, 0, 4, 4, 8)
+	m := &Minimax{Depth: 1, Eval: game.EvaluatorFunc(game.StoreEvaluator)}
+	hole, err := m.Move(context.Background(), pos)
+	assert.Nil(err)
+	assert.Equal(2, hole)
+}
+
+func TestMinimaxDeepen(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	// deepening completes several depths within the budget
+	m := &Minimax{Eval: game.EvaluatorFunc(game.StoreEvaluator), Table: NewTable(1)}
+	pos := game.StartPosition()
+	began := time.Now()
+	hole, _, err := m.Deepen(context.Background(), pos, 50*time.Millisecond)
+	assert.Nil(err)
+	assert.True(time.Since(began) < time.Second)
+	assert.True(m.Reached >= 4)
+	assert.Contains(pos.ValidMoves(), hole)
+
+	// the clock gives the budget
+	ctx := game.WithClock(context.Background(), game.Clock{MoveTime: 20 * time.Millisecond})
+	began = time.Now()
+	_, err = m.Move(ctx, pos)
+	assert.Nil(err)
+	assert.True(time.Since(began) < time.Second)
+
+	// a done context fails before the first depth
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = m.Deepen(ctx, pos, time.Second)
+	assert.Equal(context.Canceled, err)
+}
+
+func TestMinimaxThreads(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(6, 4)
+
+	pos := game.StartPosition()
+	m := &Minimax{Depth: 8, Eval: game.EvaluatorFunc(game.StoreEvaluator), Table: NewTable(4), Threads: 4}
+	hole, _, err := m.Search(context.Background(), pos)
+	assert.Nil(err)
+	assert.Contains(pos.ValidMoves(), hole)
+	// the helpers add their nodes
+	assert.True(m.Nodes > 0)
+
+	hole, _, err = m.Deepen(context.Background(), pos, 20*time.Millisecond)
+	assert.Nil(err)
+	assert.Contains(pos.ValidMoves(), hole)
+	assert.True(m.Reached >= 1)
+
+	_, err = game.CreateAgent(map[string]string{"type": "minimax", "hash": "0", "threads": "2"})
+	assert.EqualError(err, "threads 2 needs a hash table to share")
+}
+
+// benchThreads are the thread counts benchmarked
+var benchThreads = []int{1, 2, 4, 8}
+
+// BenchmarkMinimaxNodes reports the nodes searched per second
+// deepening from the start for 100ms
+func BenchmarkMinimaxNodes(b *testing.B) {
+	game.DefineGame(6, 4)
+	pos := game.StartPosition()
+	for _, threads := range benchThreads {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			m := &Minimax{Eval: game.EvaluatorFunc(game.StoreEvaluator), Table: NewTable(16), Threads: threads}
+			nodes := uint64(0)
+			began := time.Now()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := m.Deepen(context.Background(), pos, 100*time.Millisecond); err != nil {
+					b.Fatal(err)
+				}
+				nodes += m.Nodes
+			}
+			b.ReportMetric(float64(nodes)/time.Since(began).Seconds(), "nodes/s")
+		})
+	}
+}
+
+// BenchmarkMinimaxDepth times a fixed depth search from the start
+// with a fresh table
+func BenchmarkMinimaxDepth(b *testing.B) {
+	game.DefineGame(6, 4)
+	pos := game.StartPosition()
+	for _, threads := range benchThreads {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				m := &Minimax{Depth: 12, Eval: game.EvaluatorFunc(game.StoreEvaluator), Table: NewTable(16), Threads: threads}
+				b.StartTimer()
+				if _, _, err := m.Search(context.Background(), pos); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestMinimaxBook(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	dir, err := ioutil.TempDir("", "book-")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	b := book.New(4, 3, "test")
+	pos := game.StartPosition()
+	assert.Nil(b.Set(pos, []book.Move{{Hole: 2, Weight: 1}, {Hole: 1, Weight: 0}}))
+	assert.Nil(b.Save(dir))
+
+	a, err := game.CreateAgent(map[string]string{"type": "minimax", "depth": "4", "book": dir})
+	assert.Nil(err)
+	hole, err := a.Move(context.Background(), pos)
+	assert.Nil(err)
+	assert.Equal(2, hole)
+	assert.Equal(uint64(0), a.(*Minimax).Nodes)
+
+	// out of the book it searches
+	next, _ := pos.Play(2)
+	pos = next.Next.ChangePlayer()
+	hole, err = a.Move(context.Background(), pos)
+	assert.Nil(err)
+	best, _, _ := (&Minimax{Depth: 4, Eval: game.EvaluatorFunc(game.StoreEvaluator)}).Search(context.Background(), pos)
+	assert.Equal(best, hole)
+
+	_, err = game.CreateAgent(map[string]string{"type": "minimax", "book": dir + "-missing"})
+	assert.NotNil(err)
+}
+
+func TestMinimaxVerbose(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	var out strings.Builder
+	m := &Minimax{Depth: 4, Eval: game.EvaluatorFunc(game.StoreEvaluator), Verbose: true, Output: &out}
+	hole, err := m.Move(context.Background(), game.StartPosition())
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(out.String(), fmt.Sprintf("minimax > %d score ", hole)))
+	assert.True(strings.HasSuffix(out.String(), fmt.Sprintf("depth 4 nodes %d\n", m.Nodes)))
+}
+
+func TestMinimaxGameOver(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 4)
+
+	// the side to move has no stones left
+	pos := game.CreatePosition(10, 0, 0, 0, 6, 4, 3, 1)
+	m := &Minimax{Depth: 4, Eval: game.EvaluatorFunc(game.StoreEvaluator), Threads: 2, Table: NewTable(1)}
+	_, _, err := m.Search(context.Background(), pos)
+	assert.Equal(ErrGameOver, err)
+	_, _, err = m.Deepen(context.Background(), pos, time.Second)
+	assert.Equal(ErrGameOver, err)
+	_, err = m.Analyse(context.Background(), pos, 0)
+	assert.Equal(ErrGameOver, err)
+	m.Temperature = 1
+	_, err = m.Move(context.Background(), pos)
+	assert.Equal(ErrGameOver, err)
+}
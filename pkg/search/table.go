@@ -0,0 +1,154 @@
+package search
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+)
+
+// Bound is how an entry score relates to the true score
+type Bound uint8
+
+const (
+	// Exact is the true score
+	Exact Bound = iota + 1
+	// Lower bounds the score, the search having failed high
+	Lower
+	// Upper bounds the score, no move having raised alpha
+	Upper
+)
+
+// Entry is a search result for a position
+type Entry struct {
+	Key   uint64
+	Score float64
+	// Move is the best found, 0 for none
+	Move  int32
+	Depth int16
+	Bound Bound
+}
+
+// bucket holds a depth preferred and an always replaced entry
+type bucket struct {
+	deep   Entry
+	recent Entry
+}
+
+// sideKey distinguishes the second side to move
+const sideKey = 0x9e3779b97f4a7c15
+
+// Key combines the position hash and the side to move
+func Key(pos *game.Position, side int) uint64 {
+	if side != 0 {
+		return pos.Hash() ^ sideKey
+	}
+	return pos.Hash()
+}
+
+// TableStats count the use of a Table
+type TableStats struct {
+	Probes, Hits, Stores uint64
+}
+
+// HitRate is the fraction of probes finding an entry
+func (s TableStats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Probes)
+}
+
+// String summarises the stats
+func (s TableStats) String() string {
+	return fmt.Sprintf("tt hits %d/%d (%.1f%%) stores %d", s.Hits, s.Probes, 100*s.HitRate(), s.Stores)
+}
+
+// locks stripe the buckets so searches may share a Table
+const locks = 256
+
+// Table is a size bounded transposition table safe for concurrent use.
+// Each bucket keeps the deepest entry stored and the most recent,
+// so deep results survive while shallow ones still find a home.
+type Table struct {
+	// stats come first to align them for atomic use
+	stats   TableStats
+	buckets []bucket
+	mask    uint64
+	locks   [locks]sync.Mutex
+}
+
+// entrySize is the bytes of a bucket entry
+const entrySize = 24
+
+// NewTable creates a table of at most megabytes, rounded down to a
+// power of two buckets
+func NewTable(megabytes int) *Table {
+	n := uint64(1)
+	for n*2*2*entrySize <= uint64(megabytes)<<20 {
+		n *= 2
+	}
+	return &Table{buckets: make([]bucket, n), mask: n - 1}
+}
+
+// Probe finds the entry for a key
+func (t *Table) Probe(key uint64) (Entry, bool) {
+	atomic.AddUint64(&t.stats.Probes, 1)
+	i := key & t.mask
+	l := &t.locks[i%locks]
+	l.Lock()
+	b := t.buckets[i]
+	l.Unlock()
+	switch {
+	case b.deep.Bound != 0 && b.deep.Key == key:
+		atomic.AddUint64(&t.stats.Hits, 1)
+		return b.deep, true
+	case b.recent.Bound != 0 && b.recent.Key == key:
+		atomic.AddUint64(&t.stats.Hits, 1)
+		return b.recent, true
+	}
+	return Entry{}, false
+}
+
+// Store keeps an entry in the depth preferred slot when at least as
+// deep as its occupant, or of the same key, otherwise in the always
+// replaced slot
+func (t *Table) Store(e Entry) {
+	atomic.AddUint64(&t.stats.Stores, 1)
+	i := e.Key & t.mask
+	l := &t.locks[i%locks]
+	l.Lock()
+	b := &t.buckets[i]
+	if b.deep.Bound == 0 || e.Depth >= b.deep.Depth || e.Key == b.deep.Key {
+		b.deep = e
+	} else {
+		b.recent = e
+	}
+	l.Unlock()
+}
+
+// Stats are the counts since the table was created or cleared
+func (t *Table) Stats() TableStats {
+	return TableStats{
+		Probes: atomic.LoadUint64(&t.stats.Probes),
+		Hits:   atomic.LoadUint64(&t.stats.Hits),
+		Stores: atomic.LoadUint64(&t.stats.Stores),
+	}
+}
+
+// Clear removes every entry and resets the stats
+func (t *Table) Clear() {
+	for i := range t.locks {
+		t.locks[i].Lock()
+	}
+	for i := range t.buckets {
+		t.buckets[i] = bucket{}
+	}
+	atomic.StoreUint64(&t.stats.Probes, 0)
+	atomic.StoreUint64(&t.stats.Hits, 0)
+	atomic.StoreUint64(&t.stats.Stores, 0)
+	for i := range t.locks {
+		t.locks[i].Unlock()
+	}
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable(t *testing.T) {
+	assert := assert.New(t)
+
+	tt := NewTable(1)
+	assert.Len(tt.buckets, 1<<14)
+	n := uint64(len(tt.buckets))
+
+	_, ok := tt.Probe(1)
+	assert.False(ok)
+
+	// keys 1, 1+n and 1+2n share a bucket
+	tt.Store(Entry{Key: 1, Score: 2, Move: 3, Depth: 4, Bound: Exact})
+	tt.Store(Entry{Key: 1 + n, Score: 5, Depth: 2, Bound: Lower})
+	e, ok := tt.Probe(1)
+	assert.True(ok)
+	assert.Equal(Entry{Key: 1, Score: 2, Move: 3, Depth: 4, Bound: Exact}, e)
+	e, ok = tt.Probe(1 + n)
+	assert.True(ok)
+	assert.Equal(Lower, e.Bound)
+
+	// shallow entries always replace the recent slot
+	tt.Store(Entry{Key: 1 + 2*n, Score: 6, Depth: 1, Bound: Upper})
+	_, ok = tt.Probe(1 + n)
+	assert.False(ok)
+	_, ok = tt.Probe(1)
+	assert.True(ok)
+
+	// deeper entries take the depth preferred slot
+	tt.Store(Entry{Key: 1 + n, Score: 7, Depth: 5, Bound: Exact})
+	_, ok = tt.Probe(1)
+	assert.False(ok)
+
+	assert.Equal(TableStats{Probes: 6, Hits: 3, Stores: 4}, tt.Stats())
+	assert.Equal("tt hits 3/6 (50.0%) stores 4", tt.Stats().String())
+	tt.Clear()
+	_, ok = tt.Probe(1 + n)
+	assert.False(ok)
+	assert.Equal(TableStats{Probes: 1}, tt.Stats())
+}
+
+func TestKey(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(3, 4)
+
+	pos := game.StartPosition()
+	assert.Equal(pos.Hash(), Key(pos, 0))
+	assert.NotEqual(Key(pos, 0), Key(pos, 1))
+}
+
+func TestMinimaxTable(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+	rnd := rand.New(rand.NewSource(1))
+
+	// a fresh table gives the plain scores with fewer nodes
+	for g := 0; g < 3; g++ {
+		pos := game.StartPosition()
+		for !pos.IsGameEnd() {
+			for depth := 1; depth <= 6; depth++ {
+				m := &Minimax{Depth: depth, Eval: game.EvaluatorFunc(game.StoreEvaluator)}
+				hole, score, err := m.Search(context.Background(), pos)
+				assert.Nil(err)
+				tm := &Minimax{Depth: depth, Eval: m.Eval, Table: NewTable(1)}
+				tHole, tScore, err := tm.Search(context.Background(), pos)
+				assert.Nil(err)
+				assert.Equal(score, tScore, "%s depth %d", pos.AsCsv(), depth)
+				assert.Equal(hole, tHole, "%s depth %d", pos.AsCsv(), depth)
+				assert.True(tm.Nodes <= m.Nodes)
+			}
+			moves := pos.ValidMoves()
+			t, _ := pos.Play(moves[rnd.Intn(len(moves))])
+			pos = t.Next
+			if t.Result == game.EndOfTurn {
+				pos = pos.ChangePlayer()
+			}
+		}
+	}
+}
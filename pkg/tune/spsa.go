@@ -0,0 +1,206 @@
+// Package tune optimises evaluator weights by self-play
+package tune
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/EFX-PXT1/mancala-go/pkg/match"
+	"github.com/EFX-PXT1/mancala-go/pkg/search"
+)
+
+// Config describes a tuning run using simultaneous perturbation
+// stochastic approximation, SPSA
+type Config struct {
+	// Start are the initial weights of the built-in evaluators
+	Start map[string]float64
+	// Terms are the weights tuned, any other of Start being fixed
+	Terms []string
+	// Depth is that of the minimax players
+	Depth int
+	// Iterations are the steps taken
+	Iterations int
+	// Pairs of games are played each iteration
+	Pairs int
+	// Step and Perturbation are the sizes of the first iteration
+	Step, Perturbation float64
+	// OpeningPlies are random moves played before each pair of games
+	OpeningPlies int
+	// Workers play games in parallel, zero for one per CPU
+	Workers int
+	// Seed derives the perturbations and openings
+	Seed int64
+}
+
+// Step is the progress after an iteration
+type Step struct {
+	Iteration int
+	// Score is the mean of the plus side less the minus side, -1 to 1
+	Score   float64
+	Weights map[string]float64
+}
+
+// job is a game of an iteration
+type job struct {
+	start *game.Position
+	// plus is the side of the plus weights
+	plus int
+}
+
+// SPSA tunes the weights from self-play, each iteration playing
+// minimax players whose weights are perturbed in opposite directions
+// against one another and stepping towards the winner.
+// progress, if not nil, is called after each iteration.
+func SPSA(ctx context.Context, conf Config, progress func(s Step)) (map[string]float64, error) {
+	if conf.Depth < 1 || conf.Iterations < 1 || conf.Pairs < 1 {
+		return nil, fmt.Errorf("depth, iterations and pairs must be at least 1")
+	}
+	if conf.Step <= 0 || conf.Perturbation <= 0 {
+		return nil, fmt.Errorf("step and perturbation must be above 0")
+	}
+	weights := make(map[string]float64, len(conf.Start)+len(conf.Terms))
+	for term, w := range conf.Start {
+		weights[term] = w
+	}
+	terms := append([]string(nil), conf.Terms...)
+	sort.Strings(terms)
+	for _, term := range terms {
+		if _, ok := weights[term]; !ok {
+			weights[term] = 0
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no terms to tune")
+	}
+	if _, err := game.NewWeighted(weights); err != nil {
+		return nil, err
+	}
+	workers := conf.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	// gains decay as recommended by Spall
+	stability := float64(conf.Iterations) / 10
+	rnd := rand.New(rand.NewSource(conf.Seed))
+	for k := 0; k < conf.Iterations; k++ {
+		a := conf.Step / math.Pow(float64(k)+1+stability, 0.602)
+		c := conf.Perturbation / math.Pow(float64(k)+1, 0.101)
+		delta := make(map[string]float64, len(terms))
+		plus := make(map[string]float64, len(weights))
+		minus := make(map[string]float64, len(weights))
+		for term, w := range weights {
+			plus[term], minus[term] = w, w
+		}
+		for _, term := range terms {
+			delta[term] = float64(2*rnd.Intn(2) - 1)
+			plus[term] += c * delta[term]
+			minus[term] -= c * delta[term]
+		}
+
+		jobs := make([]job, 0, 2*conf.Pairs)
+		for i := 0; i < conf.Pairs; i++ {
+			start, err := match.Opening(rnd, conf.OpeningPlies)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, job{start: start, plus: 0}, job{start: start, plus: 1})
+		}
+		score, err := play(ctx, conf.Depth, plus, minus, jobs, workers)
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d: %v", k+1, err)
+		}
+
+		for _, term := range terms {
+			weights[term] += a * score / (2 * c * delta[term])
+		}
+		if progress != nil {
+			s := Step{Iteration: k + 1, Score: score, Weights: make(map[string]float64, len(weights))}
+			for term, w := range weights {
+				s.Weights[term] = w
+			}
+			progress(s)
+		}
+	}
+	return weights, nil
+}
+
+// play the games in parallel returning the mean score of the plus
+// weights less the minus weights, a draw counting zero
+func play(ctx context.Context, depth int, plus, minus map[string]float64, jobs []job, workers int) (float64, error) {
+	scores := make([]float64, len(jobs))
+	errs := make([]error, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				scores[i], errs[i] = playOne(ctx, depth, plus, minus, jobs[i])
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	sum := 0.0
+	for i := range jobs {
+		if errs[i] != nil {
+			return 0, errs[i]
+		}
+		sum += scores[i]
+	}
+	return sum / float64(len(jobs)), nil
+}
+
+// playOne plays a game scoring 1 for a plus win and -1 for a loss
+func playOne(ctx context.Context, depth int, plus, minus map[string]float64, j job) (float64, error) {
+	var agents [2]game.Agent
+	for side, weights := range []map[string]float64{plus, minus} {
+		eval, err := game.NewWeighted(weights)
+		if err != nil {
+			return 0, err
+		}
+		agents[(side+j.plus)%2] = &search.Minimax{Depth: depth, Eval: eval}
+	}
+	result, err := game.PlayGame(ctx, agents, j.start, nil)
+	if err != nil {
+		return 0, err
+	}
+	switch result.Winner {
+	case -1:
+		return 0, nil
+	case j.plus:
+		return 1, nil
+	}
+	return -1, nil
+}
+
+// WriteYAML writes weights as a named evaluator of the config file
+func WriteYAML(w io.Writer, name string, weights map[string]float64) error {
+	terms := make([]string, 0, len(weights))
+	for term := range weights {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	if _, err := fmt.Fprintf(w, "evaluators:\n  %s:\n", name); err != nil {
+		return err
+	}
+	for _, term := range terms {
+		if _, err := fmt.Fprintf(w, "    %s: %.4g\n", term, weights[term]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
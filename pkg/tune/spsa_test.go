@@ -0,0 +1,66 @@
+package tune
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/EFX-PXT1/mancala-go/pkg/game"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPSA(t *testing.T) {
+	assert := assert.New(t)
+	game.DefineGame(4, 3)
+
+	conf := Config{
+		Start:        map[string]float64{"store": 1},
+		Terms:        []string{"mobility", "captures"},
+		Depth:        2,
+		Iterations:   4,
+		Pairs:        4,
+		Step:         1,
+		Perturbation: 0.5,
+		OpeningPlies: 4,
+		Workers:      1,
+		Seed:         1,
+	}
+	var steps []Step
+	weights, err := SPSA(context.Background(), conf, func(s Step) {
+		steps = append(steps, s)
+	})
+	assert.Nil(err)
+	assert.Len(steps, 4)
+	assert.Equal(weights, steps[3].Weights)
+	// store is fixed, the tuned terms added
+	assert.Equal(1.0, weights["store"])
+	assert.Len(weights, 3)
+
+	// the same whatever the workers
+	conf.Workers = 4
+	parallel, err := SPSA(context.Background(), conf, nil)
+	assert.Nil(err)
+	assert.Equal(weights, parallel)
+
+	// a zero perturbation would divide by zero
+	bad := conf
+	bad.Perturbation = 0
+	_, err = SPSA(context.Background(), bad, nil)
+	assert.EqualError(err, "step and perturbation must be above 0")
+	bad = conf
+	bad.Step = -1
+	_, err = SPSA(context.Background(), bad, nil)
+	assert.EqualError(err, "step and perturbation must be above 0")
+
+	conf.Terms = []string{"luck"}
+	_, err = SPSA(context.Background(), conf, nil)
+	assert.EqualError(err, `invalid evaluator term "luck", must be one of: captures, material, mobility, repeats, store`)
+}
+
+func TestWriteYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	assert.Nil(WriteYAML(&b, "tuned", map[string]float64{"store": 1, "captures": 0.123456}))
+	assert.Equal("evaluators:\n  tuned:\n    captures: 0.1235\n    store: 1\n", b.String())
+}